@@ -0,0 +1,177 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"hostscanner/internal/atomicfile"
+	"hostscanner/inventory"
+	"hostscanner/oui"
+)
+
+// errUsage is returned when a command line cannot be parsed. The usage
+// text has already been printed.
+var errUsage = errors.New("invalid usage")
+
+const usage = `Usage:
+  hostscanner                              Start the terminal UI
+  hostscanner scan <range> [flags]         Scan a range and print the results
+  hostscanner diff <old> <new>             Show what changed between two saved scans
+  hostscanner inventory                    List every device seen by past scans
+  hostscanner oui update --from FILE...    Import IEEE OUI registry CSV files
+  hostscanner oui lookup MAC...            Show the vendor of MAC addresses
+`
+
+// runCommand runs the subcommand named by args[0].
+func runCommand(args []string) error {
+	switch args[0] {
+	case "scan":
+		return runScan(args[1:])
+	case "diff":
+		return runDiff(args[1:])
+	case "inventory":
+		return runInventory(args[1:])
+	case "oui":
+		return runOUI(args[1:])
+	case "help", "-h", "-help", "--help":
+		fmt.Print(usage)
+		return nil
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		return fmt.Errorf("unknown command %q", args[0])
+	}
+}
+
+// runInventory lists the devices in the inventory, most recently seen
+// first.
+func runInventory(args []string) error {
+	fs := flag.NewFlagSet("inventory", flag.ContinueOnError)
+	path := fs.String("file", "", "inventory file (default: the user inventory path)")
+	if err := fs.Parse(args); err != nil {
+		return errUsage
+	}
+
+	if *path == "" {
+		var err error
+		if *path, err = inventory.DefaultPath(); err != nil {
+			return err
+		}
+	}
+	store, err := inventory.Open(*path)
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "DEVICE\tIP\tHOSTNAME\tVENDOR\tNAME\tFIRST SEEN\tLAST SEEN\tIPS")
+	for _, d := range store.Devices() {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%d\n",
+			d.Key(), d.IP(), orDash(d.Hostname), orDash(d.Vendor), orDash(d.Labels.Name),
+			d.FirstSeen.Local().Format(time.DateTime), d.LastSeen.Local().Format(time.DateTime), len(d.IPs))
+	}
+	return tw.Flush()
+}
+
+// runOUI manages the OUI vendor database.
+func runOUI(args []string) error {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, usage)
+		return errUsage
+	}
+
+	switch args[0] {
+	case "update":
+		return runOUIUpdate(args[1:])
+	case "lookup":
+		for _, mac := range args[1:] {
+			vendor, ok := oui.Lookup(mac)
+			if !ok {
+				vendor = "Unknown"
+			}
+			fmt.Printf("%s\t%s\n", mac, vendor)
+		}
+		return nil
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		return fmt.Errorf("unknown oui command %q", args[0])
+	}
+}
+
+// runOUIUpdate builds a vendor database from IEEE registry CSV files and
+// stores it where oui.Default picks it up.
+func runOUIUpdate(args []string) error {
+	fs := flag.NewFlagSet("oui update", flag.ContinueOnError)
+	var from stringList
+	fs.Var(&from, "from", "IEEE registry CSV file (oui.csv, mam.csv, oui36.csv); repeatable")
+	out := fs.String("out", "", "output file (default: the user database path)")
+	if err := fs.Parse(args); err != nil {
+		return errUsage
+	}
+	if len(from) == 0 {
+		fs.Usage()
+		return errUsage
+	}
+
+	db := oui.New()
+	for _, path := range from {
+		n, err := importCSVFile(db, path)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Imported %d assignments from %s\n", n, path)
+	}
+
+	path := *out
+	if path == "" {
+		var err error
+		if path, err = oui.UserDBPath(); err != nil {
+			return err
+		}
+	}
+	if err := writeOUIDB(db, path); err != nil {
+		return err
+	}
+
+	fmt.Printf("Wrote %d assignments to %s\n", db.Len(), path)
+	return nil
+}
+
+func importCSVFile(db *oui.DB, path string) (int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	n, err := db.ImportCSV(f)
+	if err != nil {
+		return n, fmt.Errorf("%s: %w", path, err)
+	}
+	return n, nil
+}
+
+// writeOUIDB replaces the database at path atomically.
+func writeOUIDB(db *oui.DB, path string) error {
+	return atomicfile.Write(path, 0o644, func(w io.Writer) error {
+		_, err := db.WriteTo(w)
+		return err
+	})
+}
+
+// stringList is a flag.Value collecting repeated string flags.
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
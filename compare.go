@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"hostscanner/diff"
+	"hostscanner/report"
+)
+
+// runDiff compares two saved scans and prints what changed.
+func runDiff(args []string) error {
+	fs := flag.NewFlagSet("diff", flag.ContinueOnError)
+	format := fs.String("format", "text", "output format: text, json")
+	factor := fs.Float64("latency-factor", diff.DefaultOptions.LatencyFactor,
+		"report hosts whose latency changed by this factor; 0 disables")
+	minShift := fs.Duration("latency-min", diff.DefaultOptions.LatencyMin,
+		"smallest latency change reported")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: hostscanner diff <old> <new> [flags]")
+		fs.PrintDefaults()
+	}
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return errUsage
+	}
+	if len(positional) != 2 {
+		fs.Usage()
+		return errUsage
+	}
+	if *format != "text" && *format != "json" {
+		return fmt.Errorf("unknown output format %q", *format)
+	}
+
+	old, err := report.ReadFile(positional[0])
+	if err != nil {
+		return err
+	}
+	current, err := report.ReadFile(positional[1])
+	if err != nil {
+		return err
+	}
+
+	result := diff.Compare(old, current, diff.Options{LatencyFactor: *factor, LatencyMin: *minShift})
+	if *format == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(result)
+	}
+	return writeDiffText(os.Stdout, result)
+}
+
+// writeDiffText writes one line per change, marked + for new hosts, - for
+// disappeared hosts and ~ for changed hosts, followed by a summary.
+// A partial comparison says so, as hosts may be gone without being listed.
+func writeDiffText(w io.Writer, result *diff.Result) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, h := range result.Hosts {
+		for _, c := range h.Changes {
+			switch c.Kind {
+			case diff.HostAdded:
+				fmt.Fprintf(tw, "+\t%s\tnew host\t%s\t%s\n", h.IP, orDash(h.New.MAC), orDash(h.New.Hostname))
+			case diff.HostRemoved:
+				fmt.Fprintf(tw, "-\t%s\tdisappeared\t%s\t%s\n", h.IP, orDash(h.Old.MAC), orDash(h.Old.Hostname))
+			default:
+				fmt.Fprintf(tw, "~\t%s\t%s changed\t%s -> %s\n", h.IP, c.Kind, orDash(c.Old), orDash(c.New))
+			}
+		}
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if len(result.Hosts) > 0 {
+		fmt.Fprintln(w)
+	}
+	_, err := fmt.Fprintf(w, "%d new, %d disappeared, %d MAC changes, %d hostname changes, %d latency shifts\n",
+		result.Count(diff.HostAdded), result.Count(diff.HostRemoved), result.Count(diff.MACChanged),
+		result.Count(diff.HostnameChanged), result.Count(diff.LatencyShifted))
+	if err != nil || !result.Partial {
+		return err
+	}
+	_, err = fmt.Fprintln(w, "Partial comparison: the new scan did not check every host of the old one")
+	return err
+}
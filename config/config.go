@@ -0,0 +1,311 @@
+// Package config loads and saves the user's HostScanner configuration, a
+// YAML file in the user's configuration directory.
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+	"hostscanner/internal/atomicfile"
+)
+
+// Probe methods that can be enabled in Settings.
+const (
+	MethodICMP = "icmp" // ICMP echo requests
+	MethodTCP  = "tcp"  // TCP connections to common ports
+	MethodARP  = "arp"  // ARP requests on directly connected subnets
+)
+
+// Methods lists all probe methods.
+var Methods = []string{MethodICMP, MethodTCP, MethodARP}
+
+// Errors returned by this package.
+var (
+	ErrInvalidSettings = errors.New("invalid settings")
+	ErrUnknownProfile  = errors.New("unknown profile")
+)
+
+// Settings control how scans are run.
+type Settings struct {
+	Timeout time.Duration `yaml:"timeout"` // per host and attempt
+	Workers int           `yaml:"workers"` // hosts probed concurrently
+	Retries int           `yaml:"retries"` // extra attempts for silent hosts
+	Methods []string      `yaml:"methods"` // see Methods
+	DNS     bool          `yaml:"dns"`     // look up host names
+
+	// TCPPorts are the ports tried by TCP probes; empty for the scanner's
+	// defaults.
+	TCPPorts []int `yaml:"tcp_ports,omitempty"`
+}
+
+// DefaultSettings returns the settings used when the config file does not
+// set them.
+func DefaultSettings() Settings {
+	return Settings{
+		Timeout: time.Second,
+		Workers: 100,
+		Methods: slices.Clone(Methods),
+		DNS:     true,
+	}
+}
+
+// Validate checks that s describes a usable scan.
+func (s Settings) Validate() error {
+	switch {
+	case s.Timeout <= 0:
+		return fmt.Errorf("%w: timeout must be positive", ErrInvalidSettings)
+	case s.Workers < 1:
+		return fmt.Errorf("%w: workers must be at least 1", ErrInvalidSettings)
+	case s.Retries < 0:
+		return fmt.Errorf("%w: retries must not be negative", ErrInvalidSettings)
+	case len(s.Methods) == 0:
+		return fmt.Errorf("%w: no probe method enabled", ErrInvalidSettings)
+	}
+	for _, m := range s.Methods {
+		if !slices.Contains(Methods, m) {
+			return fmt.Errorf("%w: unknown probe method %q, want one of %s",
+				ErrInvalidSettings, m, strings.Join(Methods, ", "))
+		}
+	}
+	for _, port := range s.TCPPorts {
+		if port < 1 || port > 65535 {
+			return fmt.Errorf("%w: TCP port %d out of range", ErrInvalidSettings, port)
+		}
+	}
+
+	return nil
+}
+
+// Uses reports whether method is enabled.
+func (s Settings) Uses(method string) bool {
+	return slices.Contains(s.Methods, method)
+}
+
+// ParsePorts parses a comma-separated list of TCP ports such as
+// "22,80,443". An empty string yields no ports.
+func ParsePorts(s string) ([]int, error) {
+	var ports []int
+	for _, field := range strings.Split(s, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		port, err := strconv.Atoi(field)
+		if err != nil || port < 1 || port > 65535 {
+			return nil, fmt.Errorf("%w: invalid TCP port %q", ErrInvalidSettings, field)
+		}
+		ports = append(ports, port)
+	}
+
+	return ports, nil
+}
+
+// FormatPorts formats ports as accepted by ParsePorts.
+func FormatPorts(ports []int) string {
+	fields := make([]string, len(ports))
+	for i, port := range ports {
+		fields[i] = strconv.Itoa(port)
+	}
+
+	return strings.Join(fields, ",")
+}
+
+// Profile is a named scan definition shared through the config file. Its
+// settings override the global ones; fields left empty keep them.
+type Profile struct {
+	Targets []string      `yaml:"targets"`           // ranges as accepted by the scan command
+	Exclude []string      `yaml:"exclude,omitempty"` // ranges left out of Targets
+	Timeout time.Duration `yaml:"timeout,omitempty"`
+	Workers int           `yaml:"workers,omitempty"`
+	Retries *int          `yaml:"retries,omitempty"`
+	Methods []string      `yaml:"methods,omitempty"`
+	DNS     *bool         `yaml:"dns,omitempty"`
+	Format  string        `yaml:"format,omitempty"` // output format of the scan command
+
+	TCPPorts []int `yaml:"tcp_ports,omitempty"`
+}
+
+// Apply returns s with the settings set by p replaced.
+func (p Profile) Apply(s Settings) Settings {
+	if p.Timeout != 0 {
+		s.Timeout = p.Timeout
+	}
+	if p.Workers != 0 {
+		s.Workers = p.Workers
+	}
+	if p.Retries != nil {
+		s.Retries = *p.Retries
+	}
+	if len(p.Methods) > 0 {
+		s.Methods = slices.Clone(p.Methods)
+	}
+	if p.DNS != nil {
+		s.DNS = *p.DNS
+	}
+	if len(p.TCPPorts) > 0 {
+		s.TCPPorts = slices.Clone(p.TCPPorts)
+	}
+
+	return s
+}
+
+// Config is the content of the config file.
+type Config struct {
+	Settings Settings           `yaml:"settings"`
+	Profiles map[string]Profile `yaml:"profiles,omitempty"`
+}
+
+// Profile returns the profile called name.
+func (cfg *Config) Profile(name string) (Profile, error) {
+	p, ok := cfg.Profiles[name]
+	if !ok {
+		return Profile{}, fmt.Errorf("%w %q", ErrUnknownProfile, name)
+	}
+
+	return p, nil
+}
+
+// ProfileNames returns the names of the profiles in alphabetical order.
+func (cfg *Config) ProfileNames() []string {
+	names := make([]string, 0, len(cfg.Profiles))
+	for name := range cfg.Profiles {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	return names
+}
+
+// validate checks the settings and every profile applied to them.
+func (cfg *Config) validate() error {
+	if err := cfg.Settings.Validate(); err != nil {
+		return err
+	}
+	for _, name := range cfg.ProfileNames() {
+		p := cfg.Profiles[name]
+		if len(p.Targets) == 0 {
+			return fmt.Errorf("profile %q: %w: no targets", name, ErrInvalidSettings)
+		}
+		if err := p.Apply(cfg.Settings).Validate(); err != nil {
+			return fmt.Errorf("profile %q: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// Default returns the configuration used without a config file.
+func Default() *Config {
+	return &Config{Settings: DefaultSettings()}
+}
+
+// DefaultPath returns the location of the user's config file, under
+// $XDG_CONFIG_HOME on Linux.
+func DefaultPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "hostscanner", "config.yaml"), nil
+}
+
+// Load reads the config file at path. Settings missing from the file keep
+// their defaults, and a missing file yields the default configuration.
+func Load(path string) (*Config, error) {
+	cfg := Default()
+
+	b, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := yaml.Unmarshal(b, cfg); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if err := cfg.validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return cfg, nil
+}
+
+// Save writes cfg to path, replacing the file atomically. Comments in an
+// existing file are not preserved; use SaveSettings to change only the
+// settings of a file the user maintains.
+func (cfg *Config) Save(path string) error {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(cfg); err != nil {
+		return err
+	}
+
+	return atomicfile.Write(path, 0o644, func(w io.Writer) error {
+		_, err := w.Write(buf.Bytes())
+		return err
+	})
+}
+
+// SaveSettings replaces the settings key of the config file at path with s,
+// keeping its profiles, other keys and comments, and creates the file if it
+// does not exist. A file that is not a YAML mapping is left alone, even if
+// Load rejected it for its content.
+func SaveSettings(path string, s Settings) error {
+	b, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(b, &doc); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	if doc.Kind == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode}}}
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return fmt.Errorf("%s: not a YAML mapping", path)
+	}
+
+	var value yaml.Node
+	if err := value.Encode(s); err != nil {
+		return err
+	}
+	replaced := false
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value == "settings" {
+			root.Content[i+1] = &value
+			replaced = true
+		}
+	}
+	if !replaced {
+		root.Content = append(root.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: "settings"}, &value)
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return err
+	}
+
+	return atomicfile.Write(path, 0o644, func(w io.Writer) error {
+		_, err := w.Write(buf.Bytes())
+		return err
+	})
+}
@@ -0,0 +1,178 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"hostscanner/config"
+)
+
+func TestLoad_Missing(t *testing.T) {
+	cfg, err := config.Load(filepath.Join(t.TempDir(), "config.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, config.DefaultSettings(), cfg.Settings)
+}
+
+func TestLoad_Partial(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("settings:\n  timeout: 250ms\n  methods: [tcp]\n"), 0o644))
+
+	cfg, err := config.Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, 250*time.Millisecond, cfg.Settings.Timeout)
+	assert.Equal(t, []string{"tcp"}, cfg.Settings.Methods)
+	assert.Equal(t, 100, cfg.Settings.Workers)
+	assert.True(t, cfg.Settings.DNS)
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("settings:\n  methods: [smoke-signals]\n"), 0o644))
+
+	_, err := config.Load(path)
+	assert.ErrorIs(t, err, config.ErrInvalidSettings)
+}
+
+func TestSave_RoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "hostscanner", "config.yaml")
+	cfg := config.Default()
+	cfg.Settings.Timeout = 3 * time.Second
+	cfg.Settings.Retries = 2
+	cfg.Settings.DNS = false
+	require.NoError(t, cfg.Save(path))
+
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(b), "timeout: 3s")
+
+	loaded, err := config.Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, cfg.Settings, loaded.Settings)
+}
+
+func TestSaveSettings(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	const original = `# Scans of the office network
+settings:
+  workers: 50
+profiles:
+  office:
+    targets: [10.0.0.0/24]
+    # Slow links
+    timeout: 5s
+`
+	require.NoError(t, os.WriteFile(path, []byte(original), 0o644))
+
+	settings := config.DefaultSettings()
+	settings.Retries = 3
+	require.NoError(t, config.SaveSettings(path, settings))
+
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(b), "# Scans of the office network")
+	assert.Contains(t, string(b), "# Slow links")
+
+	cfg, err := config.Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, settings, cfg.Settings)
+	assert.Equal(t, []string{"office"}, cfg.ProfileNames())
+	assert.Equal(t, 5*time.Second, cfg.Profiles["office"].Timeout)
+}
+
+func TestSaveSettings_Unreadable(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, config.SaveSettings(path, config.DefaultSettings()))
+	cfg, err := config.Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, config.DefaultSettings(), cfg.Settings)
+
+	// A file that cannot be parsed is not replaced with the settings
+	const broken = "settings: [\nprofiles:\n  office: {}\n"
+	require.NoError(t, os.WriteFile(path, []byte(broken), 0o644))
+	assert.Error(t, config.SaveSettings(path, config.DefaultSettings()))
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, broken, string(b))
+}
+
+func TestLoad_Profiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`settings:
+  workers: 50
+profiles:
+  office-lan:
+    targets: [192.168.1.0/24]
+    exclude: [192.168.1.1]
+    methods: [arp, icmp]
+    retries: 0
+    dns: false
+    format: csv
+  lab:
+    targets: [10.0.0.1-10.0.0.20]
+    timeout: 250ms
+    tcp_ports: [22, 8080]
+`), 0o644))
+
+	cfg, err := config.Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"lab", "office-lan"}, cfg.ProfileNames())
+
+	office, err := cfg.Profile("office-lan")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"192.168.1.1"}, office.Exclude)
+	assert.Equal(t, "csv", office.Format)
+
+	s := office.Apply(cfg.Settings)
+	assert.Equal(t, []string{"arp", "icmp"}, s.Methods)
+	assert.False(t, s.DNS)
+	assert.Equal(t, 50, s.Workers)
+	assert.Equal(t, time.Second, s.Timeout)
+
+	lab, err := cfg.Profile("lab")
+	require.NoError(t, err)
+	s = lab.Apply(cfg.Settings)
+	assert.Equal(t, 250*time.Millisecond, s.Timeout)
+	assert.True(t, s.DNS)
+	assert.Equal(t, []int{22, 8080}, s.TCPPorts)
+	assert.Empty(t, office.Apply(cfg.Settings).TCPPorts)
+
+	_, err = cfg.Profile("home")
+	assert.ErrorIs(t, err, config.ErrUnknownProfile)
+}
+
+func TestLoad_InvalidProfile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("profiles:\n  lab:\n    targets: [10.0.0.0/28]\n    workers: -1\n"), 0o644))
+
+	_, err := config.Load(path)
+	assert.ErrorIs(t, err, config.ErrInvalidSettings)
+	assert.ErrorContains(t, err, `profile "lab"`)
+}
+
+func TestLoad_InvalidPort(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("settings:\n  tcp_ports: [22, 70000]\n"), 0o644))
+
+	_, err := config.Load(path)
+	assert.ErrorIs(t, err, config.ErrInvalidSettings)
+}
+
+func TestParsePorts(t *testing.T) {
+	ports, err := config.ParsePorts("22, 80,443,")
+	require.NoError(t, err)
+	assert.Equal(t, []int{22, 80, 443}, ports)
+	assert.Equal(t, "22,80,443", config.FormatPorts(ports))
+
+	ports, err = config.ParsePorts("")
+	require.NoError(t, err)
+	assert.Empty(t, ports)
+
+	for _, s := range []string{"ssh", "0", "65536", "-1"} {
+		_, err := config.ParsePorts(s)
+		assert.ErrorIs(t, err, config.ErrInvalidSettings, s)
+	}
+}
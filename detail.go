@@ -0,0 +1,268 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+
+	"hostscanner/inventory"
+	"hostscanner/scanner"
+)
+
+// detailPorts are the TCP ports checked when a host's detail page opens.
+var detailPorts = []int{21, 22, 23, 25, 53, 80, 110, 135, 139, 143, 443, 445,
+	993, 995, 1433, 3306, 3389, 5432, 5900, 8080, 8443}
+
+// latencySamples is the number of probes sent to measure the latency of a
+// host on its detail page.
+const latencySamples = 5
+
+// hostDetail is what the detail page knows about a host. The open ports
+// and latency samples are measured while the page is shown.
+type hostDetail struct {
+	host        scanner.Host
+	device      *inventory.Device // nil if not in the inventory
+	ports       []int
+	portsDone   bool
+	sampler     string // method used for the latency samples
+	samples     []time.Duration
+	lost        int
+	samplesDone bool
+}
+
+// showHostDetail opens a page with everything known about host. Press
+// Escape or q to return to the table.
+func (ui *HostScannerUI) showHostDetail(host scanner.Host) {
+	detail := &hostDetail{host: host}
+	if ui.inventory != nil {
+		detail.device, _ = ui.inventory.Lookup(host)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	view := tview.NewTextView().
+		SetDynamicColors(true).
+		SetScrollable(true).
+		SetWrap(true)
+	view.SetBorder(true).
+		SetBorderColor(tcell.ColorDarkSlateGray).
+		SetTitle(fmt.Sprintf(" 🔎 %s ", host.IP)).
+		SetTitleColor(tcell.ColorLightCyan)
+	view.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyEscape || event.Rune() == 'q' {
+			cancel()
+			ui.pages.RemovePage("detail")
+			return nil
+		}
+		return event
+	})
+
+	// update changes detail from a measuring goroutine and redraws
+	update := func(change func()) {
+		ui.app.QueueUpdateDraw(func() {
+			change()
+			view.SetText(detail.String())
+		})
+	}
+	view.SetText(detail.String())
+	ui.pages.AddPage("detail", view, true, true)
+
+	go func() {
+		portsCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+		defer cancel()
+		ports := scanner.TCPProber{Ports: detailPorts}.OpenPorts(portsCtx, host.IP)
+		if ctx.Err() != nil {
+			return
+		}
+		update(func() {
+			detail.ports = ports
+			detail.portsDone = true
+		})
+	}()
+
+	go func() {
+		prober, method, closeProber := samplingProber(host)
+		defer closeProber()
+		update(func() {
+			detail.sampler = method
+		})
+
+		for i := 0; i < latencySamples && ctx.Err() == nil; i++ {
+			probeCtx, cancel := context.WithTimeout(ctx, time.Second)
+			res, err := prober.Probe(probeCtx, host.IP)
+			cancel()
+			if ctx.Err() != nil {
+				return
+			}
+
+			update(func() {
+				if err == nil && res.Alive {
+					detail.samples = append(detail.samples, res.Latency)
+				} else {
+					detail.lost++
+				}
+			})
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(200 * time.Millisecond):
+			}
+		}
+		update(func() {
+			detail.samplesDone = true
+		})
+	}()
+}
+
+// samplingProber returns the prober used for latency samples: ICMP if a
+// socket can be opened, otherwise TCP on the port that answered the scan.
+// The returned function releases the prober.
+func samplingProber(host scanner.Host) (scanner.Prober, string, func()) {
+	if p, err := scanner.NewICMPProber(); err == nil {
+		return p, "icmp", func() { p.Close() }
+	}
+
+	ports := scanner.DefaultTCPPorts
+	if host.Port != 0 {
+		ports = []int{host.Port}
+	}
+	return scanner.TCPProber{Ports: ports}, "tcp", func() {}
+}
+
+// String renders the detail page.
+func (d *hostDetail) String() string {
+	var b strings.Builder
+	host := d.host
+
+	section := func(title string) {
+		fmt.Fprintf(&b, "\n[#00ff88::b]%s[-:-:-]\n", title)
+	}
+	field := func(name, value string) {
+		fmt.Fprintf(&b, "  [#888888]%-13s[-] %s\n", name, tview.Escape(value))
+	}
+
+	section("Host")
+	field("IP address", host.IP.String())
+	if host.IsAlive {
+		field("Status", "online")
+		field("Latency", host.Latency.Round(10*time.Microsecond).String())
+	} else {
+		field("Status", "offline")
+	}
+	field("Method", orDash(host.Method))
+	field("Evidence", orDash(host.Evidence))
+	if host.Port != 0 {
+		field("Port", fmt.Sprintf("tcp/%d", host.Port))
+	}
+	if host.Error != nil {
+		field("Error", host.Error.Error())
+	}
+
+	section("Names")
+	names := host.Names
+	if len(names) == 0 && host.Hostname != "" {
+		names = []string{host.Hostname}
+	}
+	if len(names) == 0 {
+		b.WriteString("  [#666666]No names resolved[-]\n")
+	}
+	for _, name := range names {
+		fmt.Fprintf(&b, "  %s\n", tview.Escape(name))
+	}
+
+	section("Hardware")
+	field("MAC address", orDash(host.MAC))
+	field("Vendor", orDash(host.Vendor))
+
+	section("Probes")
+	if len(host.Probes) == 0 {
+		b.WriteString("  [#666666]No probe details recorded[-]\n")
+	}
+	for _, p := range host.Probes {
+		color, outcome, detail := "#00ff88", "alive", p.Evidence
+		switch {
+		case p.Alive:
+		case errors.Is(p.Error, context.Canceled):
+			color, outcome = "#888888", "skipped"
+			detail = "another probe answered first"
+		case p.Error != nil:
+			color, outcome = "#ff4444", "failed"
+			detail = p.Error.Error()
+		default:
+			color, outcome = "#ff4444", "no reply"
+		}
+		fmt.Fprintf(&b, "  %-6s [%s]%-8s[-] %9s  %s\n", tview.Escape(p.Method), color, outcome,
+			p.Latency.Round(10*time.Microsecond), tview.Escape(detail))
+	}
+
+	section("Latency samples")
+	switch {
+	case d.sampler == "":
+		b.WriteString("  [#666666]Measuring...[-]\n")
+	default:
+		var parts []string
+		var total, low, high time.Duration
+		for i, s := range d.samples {
+			parts = append(parts, s.Round(10*time.Microsecond).String())
+			total += s
+			if i == 0 || s < low {
+				low = s
+			}
+			high = max(high, s)
+		}
+		if d.lost > 0 {
+			parts = append(parts, fmt.Sprintf("%d lost", d.lost))
+		}
+		if len(parts) == 0 {
+			parts = append(parts, "measuring...")
+		}
+		fmt.Fprintf(&b, "  %s: %s\n", d.sampler, strings.Join(parts, ", "))
+		if d.samplesDone && len(d.samples) > 0 {
+			avg := total / time.Duration(len(d.samples))
+			fmt.Fprintf(&b, "  min/avg/max %v / %v / %v\n", low.Round(10*time.Microsecond),
+				avg.Round(10*time.Microsecond), high.Round(10*time.Microsecond))
+		}
+	}
+
+	section("Open TCP ports")
+	switch {
+	case !d.portsDone:
+		fmt.Fprintf(&b, "  [#666666]Checking %d common ports...[-]\n", len(detailPorts))
+	case len(d.ports) == 0:
+		fmt.Fprintf(&b, "  None of %d common ports open\n", len(detailPorts))
+	default:
+		var ports []string
+		for _, p := range d.ports {
+			ports = append(ports, fmt.Sprint(p))
+		}
+		fmt.Fprintf(&b, "  %s\n", strings.Join(ports, ", "))
+	}
+
+	section("Inventory")
+	if d.device == nil {
+		b.WriteString("  [#666666]Not in the inventory[-]\n")
+	} else {
+		dev := d.device
+		field("Device", dev.Key())
+		field("Name", orDash(dev.Labels.Name))
+		field("Owner", orDash(dev.Labels.Owner))
+		field("Tags", orDash(strings.Join(dev.Labels.Tags, ", ")))
+		field("Notes", orDash(dev.Labels.Notes))
+		field("First seen", dev.FirstSeen.Local().Format(time.DateTime))
+		field("Last seen", dev.LastSeen.Local().Format(time.DateTime))
+		b.WriteString("  [#888888]IP history[-]\n")
+		for _, r := range dev.IPs {
+			fmt.Fprintf(&b, "    %-15s %s – %s\n", r.IP,
+				r.FirstSeen.Local().Format(time.DateTime), r.LastSeen.Local().Format(time.DateTime))
+		}
+	}
+
+	b.WriteString("\n[#444444]Press Esc or q to return[-]")
+	return b.String()
+}
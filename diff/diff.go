@@ -0,0 +1,226 @@
+// Package diff compares two scans of the same network and reports what
+// changed between them: hosts that appeared or disappeared, MAC addresses
+// and hostnames that changed, and large latency shifts.
+package diff
+
+import (
+	"bytes"
+	"net"
+	"slices"
+	"strings"
+	"time"
+
+	"hostscanner/network"
+	"hostscanner/scanner"
+)
+
+// Kind classifies a change.
+type Kind string
+
+// Kinds of change reported by Compare.
+const (
+	HostAdded       Kind = "added"    // alive now, not alive before
+	HostRemoved     Kind = "removed"  // alive before, not alive now
+	MACChanged      Kind = "mac"      // same IP answered from another MAC
+	HostnameChanged Kind = "hostname" // reverse DNS name changed
+	LatencyShifted  Kind = "latency"  // latency grew or shrank a lot
+)
+
+// Change is one difference found for a host. Old and New hold the
+// previous and current value and are empty for added and removed hosts.
+type Change struct {
+	Kind Kind   `json:"kind"`
+	Old  string `json:"old,omitempty"`
+	New  string `json:"new,omitempty"`
+}
+
+// HostDiff lists the changes for one IP address. Old and New are nil when
+// the host was not alive in the respective scan.
+type HostDiff struct {
+	IP      net.IP        `json:"ip"`
+	Old     *scanner.Host `json:"-"`
+	New     *scanner.Host `json:"-"`
+	Changes []Change      `json:"changes"`
+}
+
+// Has reports whether d contains a change of the given kind.
+func (d HostDiff) Has(kind Kind) bool {
+	return slices.ContainsFunc(d.Changes, func(c Change) bool {
+		return c.Kind == kind
+	})
+}
+
+// Options tune what counts as a change.
+type Options struct {
+	// LatencyFactor is how many times slower or faster a host must answer
+	// to be reported. Zero disables latency changes.
+	LatencyFactor float64
+
+	// LatencyMin is the smallest absolute latency difference reported,
+	// which keeps jitter on fast links quiet.
+	LatencyMin time.Duration
+}
+
+// DefaultOptions are the options used by the CLI and the terminal UI.
+var DefaultOptions = Options{
+	LatencyFactor: 3,
+	LatencyMin:    20 * time.Millisecond,
+}
+
+// Result is the outcome of a comparison. Hosts holds only the hosts that
+// changed, ordered by IP address. Partial is set when hosts of the old scan
+// were not checked by the current one, because it covered other addresses
+// or was cancelled; they are not reported as removed.
+type Result struct {
+	Hosts   []HostDiff `json:"hosts"`
+	Partial bool       `json:"partial"`
+}
+
+// Count returns the number of changes of the given kind.
+func (r *Result) Count(kind Kind) int {
+	n := 0
+	for _, h := range r.Hosts {
+		if h.Has(kind) {
+			n++
+		}
+	}
+	return n
+}
+
+// Lookup returns the changes recorded for ip.
+func (r *Result) Lookup(ip net.IP) (HostDiff, bool) {
+	i, found := slices.BinarySearchFunc(r.Hosts, ip, func(d HostDiff, ip net.IP) int {
+		return compareIP(d.IP, ip)
+	})
+	if !found {
+		return HostDiff{}, false
+	}
+	return r.Hosts[i], true
+}
+
+// Compare reports the differences between the alive hosts of old and
+// current. Offline hosts count as absent, so a host that went offline is
+// reported as removed, provided current checked its address: it is listed
+// in current, or current finished and its NetworkRange holds the address.
+func Compare(old, current *scanner.ScanResult, opts Options) *Result {
+	before := aliveHosts(old)
+	after := aliveHosts(current)
+	checked := checkedBy(current)
+
+	seen := make(map[string]bool)
+	var ips []net.IP
+	for _, hosts := range []map[string]*scanner.Host{before, after} {
+		for key, host := range hosts {
+			if !seen[key] {
+				seen[key] = true
+				ips = append(ips, host.IP)
+			}
+		}
+	}
+	slices.SortFunc(ips, compareIP)
+
+	result := &Result{}
+	for _, ip := range ips {
+		key := ip.String()
+		d := HostDiff{IP: ip, Old: before[key], New: after[key]}
+
+		switch {
+		case d.Old == nil:
+			d.Changes = append(d.Changes, Change{Kind: HostAdded})
+		case d.New == nil && !checked(ip):
+			result.Partial = true
+		case d.New == nil:
+			d.Changes = append(d.Changes, Change{Kind: HostRemoved})
+		default:
+			d.Changes = compareHost(d.Old, d.New, opts)
+		}
+
+		if len(d.Changes) > 0 {
+			result.Hosts = append(result.Hosts, d)
+		}
+	}
+
+	return result
+}
+
+// compareHost lists the changes between two sightings of the same IP.
+func compareHost(old, current *scanner.Host, opts Options) []Change {
+	var changes []Change
+
+	// A missing MAC or hostname means it could not be resolved, not that
+	// it changed
+	if old.MAC != "" && current.MAC != "" && old.MAC != current.MAC {
+		changes = append(changes, Change{Kind: MACChanged, Old: old.MAC, New: current.MAC})
+	}
+	if old.Hostname != "" && current.Hostname != "" && old.Hostname != current.Hostname {
+		changes = append(changes, Change{Kind: HostnameChanged, Old: old.Hostname, New: current.Hostname})
+	}
+	if latencyShifted(old.Latency, current.Latency, opts) {
+		changes = append(changes, Change{
+			Kind: LatencyShifted,
+			Old:  old.Latency.Round(100 * time.Microsecond).String(),
+			New:  current.Latency.Round(100 * time.Microsecond).String(),
+		})
+	}
+
+	return changes
+}
+
+// latencyShifted reports whether the latency moved by at least the
+// configured factor and minimum in either direction.
+func latencyShifted(old, current time.Duration, opts Options) bool {
+	if opts.LatencyFactor <= 0 || old <= 0 || current <= 0 {
+		return false
+	}
+
+	low, high := min(old, current), max(old, current)
+	return high-low >= opts.LatencyMin && float64(high) >= float64(low)*opts.LatencyFactor
+}
+
+// aliveHosts indexes the alive hosts of result by IP address.
+func aliveHosts(result *scanner.ScanResult) map[string]*scanner.Host {
+	hosts := make(map[string]*scanner.Host)
+	if result == nil {
+		return hosts
+	}
+
+	for i := range result.Hosts {
+		if host := &result.Hosts[i]; host.IsAlive && host.IP != nil {
+			hosts[host.IP.String()] = host
+		}
+	}
+	return hosts
+}
+
+// checkedBy returns a function reporting whether result probed ip. Hosts
+// listed in result were probed even if the scan was cancelled; the rest of
+// its NetworkRange only counts if the scan finished.
+func checkedBy(result *scanner.ScanResult) func(net.IP) bool {
+	listed := make(map[string]bool)
+	var ranges []*network.IPRange
+	if result != nil {
+		for _, host := range result.Hosts {
+			if host.IP != nil {
+				listed[host.IP.String()] = true
+			}
+		}
+		if !result.Cancelled {
+			for _, s := range strings.Split(result.NetworkRange, ",") {
+				if r, err := network.ParseIPRange(strings.TrimSpace(s)); err == nil {
+					ranges = append(ranges, r)
+				}
+			}
+		}
+	}
+
+	return func(ip net.IP) bool {
+		return listed[ip.String()] || slices.ContainsFunc(ranges, func(r *network.IPRange) bool {
+			return r.Contains(ip)
+		})
+	}
+}
+
+// compareIP orders addresses numerically by their 16-byte form.
+func compareIP(a, b net.IP) int {
+	return bytes.Compare(a.To16(), b.To16())
+}
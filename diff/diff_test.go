@@ -0,0 +1,130 @@
+package diff_test
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"hostscanner/diff"
+	"hostscanner/scanner"
+)
+
+func host(ip, mac, hostname string, latency time.Duration) scanner.Host {
+	return scanner.Host{
+		IP:       net.ParseIP(ip),
+		MAC:      mac,
+		Hostname: hostname,
+		Latency:  latency,
+		IsAlive:  true,
+	}
+}
+
+func TestCompare(t *testing.T) {
+	old := &scanner.ScanResult{Hosts: []scanner.Host{
+		host("192.168.1.1", "AA:AA:AA:00:00:01", "router", 2*time.Millisecond),
+		host("192.168.1.10", "AA:AA:AA:00:00:10", "nas", 3*time.Millisecond),
+		host("192.168.1.20", "AA:AA:AA:00:00:20", "printer", time.Millisecond),
+		host("192.168.1.30", "", "laptop", 5*time.Millisecond),
+		{IP: net.ParseIP("192.168.1.40")},
+	}}
+	current := &scanner.ScanResult{Hosts: []scanner.Host{
+		host("192.168.1.1", "AA:AA:AA:00:00:01", "router", 4*time.Millisecond),
+		host("192.168.1.10", "BB:BB:BB:00:00:10", "nas.lan", 3*time.Millisecond),
+		{IP: net.ParseIP("192.168.1.20")},
+		host("192.168.1.30", "AA:AA:AA:00:00:30", "laptop", 90*time.Millisecond),
+		host("192.168.1.40", "AA:AA:AA:00:00:40", "", time.Millisecond),
+	}}
+
+	result := diff.Compare(old, current, diff.DefaultOptions)
+	require.Len(t, result.Hosts, 4)
+
+	nas, ok := result.Lookup(net.ParseIP("192.168.1.10"))
+	require.True(t, ok)
+	assert.Equal(t, []diff.Change{
+		{Kind: diff.MACChanged, Old: "AA:AA:AA:00:00:10", New: "BB:BB:BB:00:00:10"},
+		{Kind: diff.HostnameChanged, Old: "nas", New: "nas.lan"},
+	}, nas.Changes)
+
+	printer, ok := result.Lookup(net.ParseIP("192.168.1.20"))
+	require.True(t, ok)
+	assert.True(t, printer.Has(diff.HostRemoved))
+	assert.Nil(t, printer.New)
+
+	// An unresolved MAC is not a change, but the latency jump is
+	laptop, ok := result.Lookup(net.ParseIP("192.168.1.30"))
+	require.True(t, ok)
+	assert.Equal(t, []diff.Change{{Kind: diff.LatencyShifted, Old: "5ms", New: "90ms"}}, laptop.Changes)
+
+	added, ok := result.Lookup(net.ParseIP("192.168.1.40"))
+	require.True(t, ok)
+	assert.True(t, added.Has(diff.HostAdded))
+
+	// Doubling from 2ms to 4ms is jitter, not a shift
+	_, ok = result.Lookup(net.ParseIP("192.168.1.1"))
+	assert.False(t, ok)
+
+	assert.Equal(t, 1, result.Count(diff.HostAdded))
+	assert.Equal(t, 1, result.Count(diff.HostRemoved))
+	assert.Equal(t, 1, result.Count(diff.MACChanged))
+}
+
+func TestCompare_Identical(t *testing.T) {
+	scan := &scanner.ScanResult{Hosts: []scanner.Host{
+		host("10.0.0.1", "AA:AA:AA:00:00:01", "gw", time.Millisecond),
+	}}
+
+	assert.Empty(t, diff.Compare(scan, scan, diff.DefaultOptions).Hosts)
+}
+
+func TestCompare_UnresolvedHostname(t *testing.T) {
+	old := &scanner.ScanResult{Hosts: []scanner.Host{
+		host("10.0.0.1", "AA:AA:AA:00:00:01", "gw", time.Millisecond),
+		host("10.0.0.2", "AA:AA:AA:00:00:02", "", time.Millisecond),
+	}}
+	current := &scanner.ScanResult{Hosts: []scanner.Host{
+		host("10.0.0.1", "AA:AA:AA:00:00:01", "", time.Millisecond),
+		host("10.0.0.2", "AA:AA:AA:00:00:02", "nas", time.Millisecond),
+	}}
+
+	assert.Empty(t, diff.Compare(old, current, diff.DefaultOptions).Hosts)
+}
+
+func TestCompare_OutsideRange(t *testing.T) {
+	old := &scanner.ScanResult{Hosts: []scanner.Host{
+		host("10.0.0.1", "AA:AA:AA:00:00:01", "gw", time.Millisecond),
+		host("10.0.1.1", "AA:AA:AA:00:01:01", "nas", time.Millisecond),
+	}}
+	current := &scanner.ScanResult{NetworkRange: "10.0.0.0/24"}
+
+	result := diff.Compare(old, current, diff.DefaultOptions)
+	require.Len(t, result.Hosts, 1)
+	assert.True(t, result.Hosts[0].Has(diff.HostRemoved))
+	assert.Equal(t, "10.0.0.1", result.Hosts[0].IP.String())
+	assert.True(t, result.Partial)
+
+	current.NetworkRange = "10.0.0.0/24, 10.0.1.0/24"
+	result = diff.Compare(old, current, diff.DefaultOptions)
+	assert.Equal(t, 2, result.Count(diff.HostRemoved))
+	assert.False(t, result.Partial)
+}
+
+func TestCompare_Cancelled(t *testing.T) {
+	old := &scanner.ScanResult{Hosts: []scanner.Host{
+		host("10.0.0.1", "AA:AA:AA:00:00:01", "gw", time.Millisecond),
+		host("10.0.0.2", "AA:AA:AA:00:00:02", "nas", time.Millisecond),
+	}}
+	current := &scanner.ScanResult{
+		NetworkRange: "10.0.0.0/24",
+		Cancelled:    true,
+		Hosts:        []scanner.Host{{IP: net.ParseIP("10.0.0.1")}},
+	}
+
+	// Only the host the cancelled scan got to counts as gone
+	result := diff.Compare(old, current, diff.DefaultOptions)
+	require.Len(t, result.Hosts, 1)
+	assert.True(t, result.Hosts[0].Has(diff.HostRemoved))
+	assert.Equal(t, "10.0.0.1", result.Hosts[0].IP.String())
+	assert.True(t, result.Partial)
+}
@@ -0,0 +1,50 @@
+package atomicfile_test
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"hostscanner/internal/atomicfile"
+)
+
+func TestWrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sub", "file.txt")
+	require.NoError(t, atomicfile.Write(path, 0o644, func(w io.Writer) error {
+		_, err := io.WriteString(w, "first")
+		return err
+	}))
+
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "first", string(b))
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o644), info.Mode().Perm())
+}
+
+func TestWrite_ErrorKeepsFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "file.txt")
+	require.NoError(t, os.WriteFile(path, []byte("old"), 0o644))
+
+	errWrite := errors.New("write failed")
+	err := atomicfile.Write(path, 0o644, func(w io.Writer) error {
+		io.WriteString(w, "partial")
+		return errWrite
+	})
+	assert.ErrorIs(t, err, errWrite)
+
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "old", string(b))
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "temporary file left behind")
+}
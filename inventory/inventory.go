@@ -0,0 +1,311 @@
+// Package inventory keeps a persistent record of every device seen by a
+// scan: when it was first and last seen, which IP addresses it used and
+// the labels a user gave it.
+//
+// Devices are keyed by MAC address. Hosts whose MAC address could not be
+// resolved, such as hosts behind a router, are keyed by IP address until a
+// scan learns their MAC address.
+package inventory
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"hostscanner/internal/atomicfile"
+	"hostscanner/scanner"
+)
+
+// FormatVersion is the version of the inventory file format.
+const FormatVersion = 1
+
+// ErrUnsupportedFormat is returned when the inventory file was written by
+// a newer version.
+var ErrUnsupportedFormat = errors.New("unsupported inventory format")
+
+// Device is one device of the inventory.
+type Device struct {
+	MAC       string     `json:"mac,omitempty"`
+	Hostname  string     `json:"hostname,omitempty"`
+	Vendor    string     `json:"vendor,omitempty"`
+	FirstSeen time.Time  `json:"first_seen"`
+	LastSeen  time.Time  `json:"last_seen"`
+	IPs       []IPRecord `json:"ips"`
+	Labels    Labels     `json:"labels"`
+}
+
+// IPRecord is an IP address a device used and when it was seen with it.
+type IPRecord struct {
+	IP        string    `json:"ip"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+}
+
+// Labels are the details a user assigned to a device.
+type Labels struct {
+	Name  string   `json:"name,omitempty"`
+	Owner string   `json:"owner,omitempty"`
+	Tags  []string `json:"tags,omitempty"`
+	Notes string   `json:"notes,omitempty"`
+}
+
+// IsZero reports whether no label is set.
+func (l Labels) IsZero() bool {
+	return l.Name == "" && l.Owner == "" && len(l.Tags) == 0 && l.Notes == ""
+}
+
+// Key returns the key identifying d: its MAC address, or its IP address
+// if the MAC address is unknown.
+func (d *Device) Key() string {
+	if d.MAC != "" {
+		return d.MAC
+	}
+	return d.IP()
+}
+
+// IP returns the address the device was last seen with.
+func (d *Device) IP() string {
+	if len(d.IPs) == 0 {
+		return ""
+	}
+
+	latest := d.IPs[0]
+	for _, r := range d.IPs[1:] {
+		if !r.LastSeen.Before(latest.LastSeen) {
+			latest = r
+		}
+	}
+	return latest.IP
+}
+
+// see records that d was seen with ip at time t.
+func (d *Device) see(ip string, t time.Time) {
+	if d.FirstSeen.IsZero() || t.Before(d.FirstSeen) {
+		d.FirstSeen = t
+	}
+	if t.After(d.LastSeen) {
+		d.LastSeen = t
+	}
+
+	for i := range d.IPs {
+		if d.IPs[i].IP == ip {
+			if t.After(d.IPs[i].LastSeen) {
+				d.IPs[i].LastSeen = t
+			}
+			return
+		}
+	}
+	d.IPs = append(d.IPs, IPRecord{IP: ip, FirstSeen: t, LastSeen: t})
+}
+
+// Store is an inventory held in memory. Use Open to load one from a file
+// and Save to write it back.
+type Store struct {
+	path    string
+	devices map[string]*Device // by Key
+}
+
+// file is the on-disk layout of a Store.
+type file struct {
+	Version int       `json:"version"`
+	Devices []*Device `json:"devices"`
+}
+
+// DefaultPath returns the location of the user's inventory file.
+func DefaultPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "hostscanner", "inventory.json"), nil
+}
+
+// Open loads the inventory stored at path. A missing file yields an empty
+// inventory that is created on the first Save.
+func Open(path string) (*Store, error) {
+	s := &Store{path: path, devices: make(map[string]*Device)}
+
+	b, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var f file
+	if err := json.Unmarshal(b, &f); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if f.Version > FormatVersion {
+		return nil, fmt.Errorf("%s: %w: %d", path, ErrUnsupportedFormat, f.Version)
+	}
+	for _, d := range f.Devices {
+		s.devices[d.Key()] = d
+	}
+
+	return s, nil
+}
+
+// Path returns the file the store is saved to.
+func (s *Store) Path() string {
+	return s.path
+}
+
+// Len returns the number of devices.
+func (s *Store) Len() int {
+	return len(s.devices)
+}
+
+// Devices returns all devices ordered by the time they were last seen,
+// most recent first.
+func (s *Store) Devices() []*Device {
+	devices := make([]*Device, 0, len(s.devices))
+	for _, d := range s.devices {
+		devices = append(devices, d)
+	}
+	slices.SortFunc(devices, func(a, b *Device) int {
+		if c := b.LastSeen.Compare(a.LastSeen); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Key(), b.Key())
+	})
+
+	return devices
+}
+
+// Lookup returns the device a host belongs to: the device with the host's
+// MAC address or, if the MAC address is unknown, the device last seen with
+// the host's IP address.
+func (s *Store) Lookup(host scanner.Host) (*Device, bool) {
+	if host.MAC != "" {
+		d, ok := s.devices[host.MAC]
+		return d, ok
+	}
+	if host.IP == nil {
+		return nil, false
+	}
+
+	ip := host.IP.String()
+	if d, ok := s.devices[ip]; ok {
+		return d, true
+	}
+
+	var found *Device
+	for _, d := range s.devices {
+		if d.IP() == ip && (found == nil || d.LastSeen.After(found.LastSeen)) {
+			found = d
+		}
+	}
+	return found, found != nil
+}
+
+// Record adds the alive hosts of a scan to the inventory. Hosts are
+// considered seen when the scan finished.
+func (s *Store) Record(result *scanner.ScanResult) {
+	seen := result.StartTime.Add(result.ScanTime)
+	if result.StartTime.IsZero() {
+		seen = time.Now()
+	}
+	seen = seen.UTC().Truncate(time.Second)
+
+	for _, host := range result.Hosts {
+		if host.IsAlive && host.IP != nil {
+			s.record(host, seen)
+		}
+	}
+}
+
+// record adds one sighting of host.
+func (s *Store) record(host scanner.Host, t time.Time) {
+	ip := host.IP.String()
+
+	d, ok := s.Lookup(host)
+	if !ok && host.MAC != "" {
+		// A device first seen without a MAC address is keyed by IP;
+		// move it over now that its MAC address is known.
+		if d, ok = s.devices[ip]; ok {
+			delete(s.devices, ip)
+			d.MAC = host.MAC
+			s.devices[d.MAC] = d
+		}
+	}
+	if !ok {
+		d = &Device{MAC: host.MAC}
+	}
+
+	d.see(ip, t)
+	if host.Hostname != "" {
+		d.Hostname = host.Hostname
+	}
+	if host.Vendor != "" && host.Vendor != "Unknown" {
+		d.Vendor = host.Vendor
+	}
+	s.devices[d.Key()] = d
+}
+
+// SetLabels replaces the labels of the device with the given key.
+func (s *Store) SetLabels(key string, labels Labels) error {
+	d, ok := s.devices[key]
+	if !ok {
+		return fmt.Errorf("no device %q in inventory", key)
+	}
+
+	d.Labels = labels
+	return nil
+}
+
+// Save writes the inventory back to its file. The file is replaced
+// atomically, so a concurrent Open never sees a partial inventory.
+func (s *Store) Save() error {
+	b, err := json.MarshalIndent(file{Version: FormatVersion, Devices: s.Devices()}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return atomicfile.Write(s.path, 0o644, func(w io.Writer) error {
+		_, err := w.Write(append(b, '\n'))
+		return err
+	})
+}
+
+// Edit loads the inventory stored at path, applies edit and saves it. A
+// lock file beside the inventory is held throughout, so concurrent edits,
+// such as a scan recording its hosts while labels are changed, do not
+// overwrite each other. The store is not saved if edit fails.
+func Edit(path string, edit func(*Store) error) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	unlock, err := lockFile(path + ".lock")
+	if err != nil {
+		return fmt.Errorf("failed to lock inventory: %w", err)
+	}
+	defer unlock()
+
+	s, err := Open(path)
+	if err != nil {
+		return err
+	}
+	if err := edit(s); err != nil {
+		return err
+	}
+
+	return s.Save()
+}
+
+// Update records a scan in the inventory stored at path.
+func Update(path string, result *scanner.ScanResult) error {
+	return Edit(path, func(s *Store) error {
+		s.Record(result)
+		return nil
+	})
+}
@@ -0,0 +1,118 @@
+package inventory_test
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"hostscanner/inventory"
+	"hostscanner/scanner"
+)
+
+func scanAt(t time.Time, hosts ...scanner.Host) *scanner.ScanResult {
+	return &scanner.ScanResult{StartTime: t, Hosts: hosts}
+}
+
+func alive(ip, mac string) scanner.Host {
+	return scanner.Host{IP: net.ParseIP(ip), MAC: mac, IsAlive: true}
+}
+
+func TestStore_Record(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "inventory.json")
+	day1 := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	day2 := day1.Add(24 * time.Hour)
+
+	require.NoError(t, inventory.Update(path, scanAt(day1,
+		alive("192.168.1.10", "AA:AA:AA:00:00:10"),
+		alive("192.168.1.20", ""),
+		scanner.Host{IP: net.ParseIP("192.168.1.30")},
+	)))
+
+	store, err := inventory.Open(path)
+	require.NoError(t, err)
+	require.Equal(t, 2, store.Len())
+	require.NoError(t, store.SetLabels("192.168.1.20", inventory.Labels{Name: "printer"}))
+	require.NoError(t, store.Save())
+
+	// The first device moved to a new address; the second got its MAC
+	// resolved and keeps its labels.
+	require.NoError(t, inventory.Update(path, scanAt(day2,
+		alive("192.168.1.11", "AA:AA:AA:00:00:10"),
+		alive("192.168.1.20", "BB:BB:BB:00:00:20"),
+	)))
+
+	store, err = inventory.Open(path)
+	require.NoError(t, err)
+	require.Equal(t, 2, store.Len())
+
+	laptop, ok := store.Lookup(alive("192.168.1.11", "AA:AA:AA:00:00:10"))
+	require.True(t, ok)
+	assert.Equal(t, day1, laptop.FirstSeen)
+	assert.Equal(t, day2, laptop.LastSeen)
+	assert.Equal(t, "192.168.1.11", laptop.IP())
+	assert.Equal(t, []inventory.IPRecord{
+		{IP: "192.168.1.10", FirstSeen: day1, LastSeen: day1},
+		{IP: "192.168.1.11", FirstSeen: day2, LastSeen: day2},
+	}, laptop.IPs)
+
+	printer, ok := store.Lookup(alive("192.168.1.20", ""))
+	require.True(t, ok)
+	assert.Equal(t, "BB:BB:BB:00:00:20", printer.Key())
+	assert.Equal(t, "printer", printer.Labels.Name)
+	assert.Equal(t, day1, printer.FirstSeen)
+
+	_, ok = store.Lookup(alive("192.168.1.30", ""))
+	assert.False(t, ok)
+}
+
+func TestOpen_Missing(t *testing.T) {
+	store, err := inventory.Open(filepath.Join(t.TempDir(), "missing.json"))
+	require.NoError(t, err)
+	assert.Zero(t, store.Len())
+}
+
+func TestOpen_FutureVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "inventory.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"version": 99, "devices": []}`), 0o644))
+
+	_, err := inventory.Open(path)
+	assert.ErrorIs(t, err, inventory.ErrUnsupportedFormat)
+}
+
+func TestEdit_Concurrent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "inventory.json")
+	day := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	require.NoError(t, inventory.Update(path, scanAt(day, alive("10.0.0.1", "AA:AA:AA:00:00:01"))))
+
+	// Scans and a label change racing for the file all keep their updates
+	var wg sync.WaitGroup
+	for i := 2; i <= 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ip := fmt.Sprintf("10.0.0.%d", i)
+			assert.NoError(t, inventory.Update(path, scanAt(day, alive(ip, ""))))
+		}()
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		assert.NoError(t, inventory.Edit(path, func(s *inventory.Store) error {
+			return s.SetLabels("AA:AA:AA:00:00:01", inventory.Labels{Name: "gateway"})
+		}))
+	}()
+	wg.Wait()
+
+	store, err := inventory.Open(path)
+	require.NoError(t, err)
+	assert.Equal(t, 20, store.Len())
+	gateway, ok := store.Lookup(alive("10.0.0.1", "AA:AA:AA:00:00:01"))
+	require.True(t, ok)
+	assert.Equal(t, "gateway", gateway.Labels.Name)
+}
@@ -0,0 +1,8 @@
+//go:build !unix && !windows
+
+package inventory
+
+// lockFile does nothing where file locks are not available.
+func lockFile(path string) (func(), error) {
+	return func() {}, nil
+}
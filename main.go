@@ -1,15 +1,24 @@
 package main
 
 import (
+	"bytes"
+	"cmp"
+	"context"
 	"fmt"
 	"os"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
 
+	"hostscanner/config"
+	"hostscanner/diff"
+	"hostscanner/inventory"
 	"hostscanner/network"
+	"hostscanner/report"
 	"hostscanner/scanner"
 )
 
@@ -27,12 +36,48 @@ type HostScannerUI struct {
 	progressBar  *tview.TextView
 	scanButton   *tview.Button
 	ipInput      *tview.InputField
+	profileList  *tview.DropDown
 	showInactive *tview.Checkbox
+	hideUnknown  *tview.Checkbox
+	filterInput  *tview.InputField
 	isScanning   bool
+	config       *config.Config
+	profile      string // selected profile, empty for none
+	cancelScan   context.CancelFunc
+	progress     scanner.Progress
 	scanResults  *scanner.ScanResult
+	baseline     *scanner.ScanResult // earlier scan to compare against
+	changes      *diff.Result        // scanResults compared to baseline
+	inventory    *inventory.Store    // nil if the inventory cannot be read
+	hosts        []scanner.Host      // hosts of the current results, as they arrive
+	offlineKept  bool                // the current results list offline hosts
+	sortColumn   int                 // table column the rows are sorted by
+	sortDesc     bool
+	tableStale   bool // rows were appended out of order during a scan
 }
 
+// Columns of the results table.
+const (
+	colStatus = iota
+	colIP
+	colHostname
+	colMAC
+	colVendor
+	colLatency
+	colLabel
+)
+
 func main() {
+	if len(os.Args) > 1 {
+		if err := runCommand(os.Args[1:]); err != nil {
+			if err != errUsage {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			}
+			os.Exit(1)
+		}
+		return
+	}
+
 	ui := NewHostScannerUI()
 	if err := ui.Run(); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
@@ -43,10 +88,24 @@ func main() {
 // NewHostScannerUI creates a new instance of the host scanner UI.
 func NewHostScannerUI() *HostScannerUI {
 	ui := &HostScannerUI{
-		app: tview.NewApplication(),
+		app:        tview.NewApplication(),
+		sortColumn: colIP,
 	}
 
+	// The profile list in the sidebar is filled from the config
+	cfg, err := loadConfig()
+	if err != nil {
+		cfg = config.Default()
+	}
+	ui.config = cfg
+
 	ui.setupModernUI()
+	ui.inventory = openInventory()
+
+	if err != nil {
+		ui.showModernError(fmt.Sprintf("Using default settings, the config file could not be read: %v", err))
+	}
+
 	return ui
 }
 
@@ -93,6 +152,18 @@ func (ui *HostScannerUI) createSidebar() {
 		SetFieldBackgroundColor(tcell.ColorDarkSlateGray).
 		SetFieldTextColor(tcell.ColorWhite)
 
+	// Profiles fill in the targets and override the settings of a scan
+	ui.profileList = tview.NewDropDown().
+		SetLabel("📁 Profile ").
+		SetOptions(append([]string{"(none)"}, ui.config.ProfileNames()...), nil).
+		SetCurrentOption(0).
+		SetLabelColor(tcell.ColorLightBlue).
+		SetFieldBackgroundColor(tcell.ColorDarkSlateGray).
+		SetFieldTextColor(tcell.ColorWhite)
+	ui.profileList.SetSelectedFunc(func(name string, index int) {
+		ui.selectProfile(name, index)
+	})
+
 	// Checkbox with modern styling
 	ui.showInactive = tview.NewCheckbox().
 		SetLabel("👻 Show offline hosts").
@@ -100,6 +171,25 @@ func (ui *HostScannerUI) createSidebar() {
 		SetFieldBackgroundColor(tcell.ColorDarkSlateGray).
 		SetFieldTextColor(tcell.ColorWhite)
 
+	ui.hideUnknown = tview.NewCheckbox().
+		SetLabel("🏢 Hide unknown vendors").
+		SetLabelColor(tcell.ColorLightGray).
+		SetFieldBackgroundColor(tcell.ColorDarkSlateGray).
+		SetFieldTextColor(tcell.ColorWhite)
+
+	// View options only change which rows are shown, so the table is
+	// redrawn from the stored results without scanning again. Scans only
+	// keep offline hosts while they are shown, though.
+	ui.hideUnknown.SetChangedFunc(func(bool) {
+		ui.renderTable()
+	})
+	ui.showInactive.SetChangedFunc(func(checked bool) {
+		ui.renderTable()
+		if checked && ui.scanResults != nil && !ui.offlineKept {
+			ui.updateProgressBar("Offline hosts are kept from the next scan on", ui.progress.Percent())
+		}
+	})
+
 	// Scan button with modern styling
 	ui.scanButton = tview.NewButton("🚀 Start Scan")
 	ui.scanButton.SetSelectedFunc(ui.scanNetwork).
@@ -111,6 +201,21 @@ func (ui *HostScannerUI) createSidebar() {
 		SetLabelColor(tcell.ColorBlack).
 		SetBackgroundColor(tcell.ColorLightBlue)
 
+	exportBtn := tview.NewButton("💾 Export")
+	exportBtn.SetSelectedFunc(ui.showExportForm).
+		SetLabelColor(tcell.ColorBlack).
+		SetBackgroundColor(tcell.ColorLightYellow)
+
+	settingsBtn := tview.NewButton("⚙️ Settings")
+	settingsBtn.SetSelectedFunc(ui.showSettingsForm).
+		SetLabelColor(tcell.ColorBlack).
+		SetBackgroundColor(tcell.ColorLightBlue)
+
+	importBtn := tview.NewButton("📂 Import")
+	importBtn.SetSelectedFunc(ui.showImportForm).
+		SetLabelColor(tcell.ColorBlack).
+		SetBackgroundColor(tcell.ColorLightYellow)
+
 	quitBtn := tview.NewButton("❌ Quit")
 	quitBtn.SetSelectedFunc(func() { ui.app.Stop() }).
 		SetLabelColor(tcell.ColorWhite).
@@ -137,17 +242,25 @@ func (ui *HostScannerUI) createSidebar() {
 	ui.sidebar = tview.NewFlex().SetDirection(tview.FlexRow).
 		AddItem(tview.NewTextView().SetText("[#00ff88::b]⚙️  Configuration").SetDynamicColors(true), 1, 0, false).
 		AddItem(tview.NewTextView(), 1, 0, false). // Spacer
+		AddItem(ui.profileList, 1, 0, false).
 		AddItem(ui.ipInput, 1, 0, false).
 		AddItem(tview.NewTextView(), 1, 0, false). // Spacer
 		AddItem(ui.showInactive, 1, 0, false).
+		AddItem(ui.hideUnknown, 1, 0, false).
 		AddItem(tview.NewTextView(), 2, 0, false). // Spacer
 		AddItem(ui.scanButton, 1, 0, false).
 		AddItem(tview.NewTextView(), 1, 0, false). // Spacer
 		AddItem(autoDetectBtn, 1, 0, false).
 		AddItem(tview.NewTextView(), 1, 0, false). // Spacer
+		AddItem(settingsBtn, 1, 0, false).
+		AddItem(tview.NewTextView(), 1, 0, false). // Spacer
+		AddItem(exportBtn, 1, 0, false).
+		AddItem(tview.NewTextView(), 1, 0, false). // Spacer
+		AddItem(importBtn, 1, 0, false).
+		AddItem(tview.NewTextView(), 1, 0, false). // Spacer
 		AddItem(quitBtn, 1, 0, false).
 		AddItem(tview.NewTextView(), 2, 0, false). // Spacer
-		AddItem(ui.progressBar, 1, 0, false).
+		AddItem(ui.progressBar, 2, 0, false).
 		AddItem(tview.NewTextView(), 1, 0, false). // Spacer
 		AddItem(ui.infoPanel, 0, 1, false)
 
@@ -164,10 +277,61 @@ func (ui *HostScannerUI) createContentArea() {
 		SetSelectable(true, false).
 		SetSelectedStyle(tcell.StyleDefault.Background(tcell.ColorDarkSlateGray).Foreground(tcell.ColorWhite)).
 		SetFixed(1, 0)
+	ui.table.SetSelectedFunc(func(row, _ int) {
+		if host, ok := ui.table.GetCell(row, colIP).GetReference().(scanner.Host); ok {
+			ui.showLabelForm(host)
+		}
+	})
+	ui.table.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch r := event.Rune(); {
+		case r == 'd':
+			row, _ := ui.table.GetSelection()
+			if host, ok := ui.table.GetCell(row, colIP).GetReference().(scanner.Host); ok {
+				ui.showHostDetail(host)
+			}
+		case r == '/':
+			ui.app.SetFocus(ui.filterInput)
+		case r >= '1' && r <= '7':
+			ui.sortBy(int(r - '1'))
+		default:
+			return event
+		}
+		return nil
+	})
+	ui.table.SetMouseCapture(func(action tview.MouseAction, event *tcell.EventMouse) (tview.MouseAction, *tcell.EventMouse) {
+		// Clicking a header sorts by its column
+		if action == tview.MouseLeftClick || action == tview.MouseLeftDoubleClick {
+			if row, col := ui.table.CellAt(event.Position()); row == 0 && col >= 0 {
+				ui.sortBy(col)
+				return action, nil
+			}
+		}
+		return action, event
+	})
+
+	ui.filterInput = tview.NewInputField().
+		SetLabel("🔎 Filter ").
+		SetPlaceholder("press / to filter by IP, hostname, MAC or vendor").
+		SetFieldWidth(0).
+		SetLabelColor(tcell.ColorLightBlue).
+		SetFieldBackgroundColor(tcell.ColorBlack).
+		SetFieldTextColor(tcell.ColorWhite).
+		SetPlaceholderTextColor(tcell.ColorDimGray).
+		SetChangedFunc(func(string) {
+			ui.renderTable()
+			ui.table.Select(1, 0).ScrollToBeginning()
+		})
+	ui.filterInput.SetDoneFunc(func(key tcell.Key) {
+		if key == tcell.KeyEscape {
+			ui.filterInput.SetText("")
+		}
+		ui.app.SetFocus(ui.table)
+	})
 
 	ui.setupModernTable()
 
 	ui.contentArea = tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(ui.filterInput, 1, 0, false).
 		AddItem(ui.table, 0, 1, false)
 
 	ui.contentArea.SetBorder(true).
@@ -188,12 +352,22 @@ func (ui *HostScannerUI) setupModernTable() {
 		{"🔧 MAC Address", tview.AlignLeft},
 		{"🏢 Vendor", tview.AlignLeft},
 		{"⚡ Latency", tview.AlignRight},
+		{"🏷️ Label", tview.AlignLeft},
 	}
 
 	// Define expansion settings for each column to match data cells
-	expansions := []int{0, 0, 1, 0, 1, 0} // Status, IP, Hostname, MAC, Vendor, Latency
+	expansions := []int{0, 0, 1, 0, 1, 0, 1} // Status, IP, Hostname, MAC, Vendor, Latency, Label
 
 	for col, header := range headers {
+		// Mark the column the rows are sorted by
+		if col == ui.sortColumn {
+			if ui.sortDesc {
+				header.text += " ▼"
+			} else {
+				header.text += " ▲"
+			}
+		}
+
 		cell := tview.NewTableCell(header.text).
 			SetAlign(header.align).
 			SetSelectable(false).
@@ -209,7 +383,7 @@ func (ui *HostScannerUI) createFooter() {
 	ui.footer = tview.NewTextView().
 		SetDynamicColors(true).
 		SetTextAlign(tview.AlignCenter).
-		SetText("[#444444]Press [#00ff88::b]Tab[#444444] to navigate • [#00ff88::b]Enter[#444444] to select • [#00ff88::b]Ctrl+C[#444444] to quit")
+		SetText("[#444444]Press [#00ff88::b]Tab[#444444] to navigate • [#00ff88::b]Enter[#444444] to select or label • [#00ff88::b]d[#444444] for host details • [#00ff88::b]1-7[#444444] to sort • [#00ff88::b]/[#444444] to filter • [#00ff88::b]Ctrl+C[#444444] to quit")
 }
 
 func (ui *HostScannerUI) setupLayout() {
@@ -250,7 +424,10 @@ func (ui *HostScannerUI) updateInfoPanel() {
 	totalHosts := ui.scanResults.TotalHosts
 	scanTime := ui.scanResults.ScanTime
 
-	percentage := float64(activeHosts) / float64(totalHosts) * 100
+	percentage := 0.0
+	if totalHosts > 0 {
+		percentage = float64(activeHosts) / float64(totalHosts) * 100
+	}
 
 	statusColor := "#ff4444"
 	if percentage > 50 {
@@ -275,6 +452,20 @@ func (ui *HostScannerUI) updateInfoPanel() {
 		scanTime.Truncate(time.Millisecond),
 		time.Now().Format("15:04:05"))
 
+	if c := ui.changes; c != nil {
+		info += fmt.Sprintf(`
+
+[#ffaa00::b]🔀 Since Baseline
+[#00ff88]New:[#ffffff] %d  [#888888]Gone:[#ffffff] %d
+[#ff4444]MAC changed:[#ffffff] %d
+[#ffaa00]Other changes:[#ffffff] %d`,
+			c.Count(diff.HostAdded), c.Count(diff.HostRemoved), c.Count(diff.MACChanged),
+			len(c.Hosts)-c.Count(diff.HostAdded)-c.Count(diff.HostRemoved)-c.Count(diff.MACChanged))
+		if c.Partial {
+			info += "\n[#888888]Partial: not every baseline host was scanned"
+		}
+	}
+
 	ui.infoPanel.SetText(info)
 }
 
@@ -284,50 +475,152 @@ func (ui *HostScannerUI) Run() error {
 
 func (ui *HostScannerUI) scanNetwork() {
 	if ui.isScanning {
+		// The scan button doubles as a stop button while scanning.
+		if ui.cancelScan != nil {
+			ui.cancelScan()
+			ui.updateProgressBar("Stopping scan...", ui.progress.Percent())
+		}
 		return
 	}
 
 	ipRange := ui.ipInput.GetText()
-	if ipRange == "" {
+	ranges := splitTargets(ipRange)
+	if len(ranges) == 0 {
 		ui.showModernError("Please enter an IP range")
 		return
 	}
 
+	targets, err := expandTargets(ranges, ui.activeProfile().Exclude)
+	if err != nil {
+		ui.showModernError(err.Error())
+		return
+	}
+
+	ui.startScan(ipRange, targets)
+}
+
+// selectProfile makes the profile at index of the profile list the active
+// one and shows its targets. The first entry selects no profile.
+func (ui *HostScannerUI) selectProfile(name string, index int) {
+	if index <= 0 {
+		ui.profile = ""
+		return
+	}
+
+	ui.profile = name
+	ui.ipInput.SetText(strings.Join(ui.activeProfile().Targets, ", "))
+	ui.updateProgressBar(fmt.Sprintf("Using profile %s", name), 0)
+}
+
+// activeProfile returns the selected profile, or an empty profile that
+// changes nothing if none is selected.
+func (ui *HostScannerUI) activeProfile() config.Profile {
+	if ui.profile == "" {
+		return config.Profile{}
+	}
+
+	p, _ := ui.config.Profile(ui.profile)
+	return p
+}
+
+// startScan probes targets in the background, streaming hosts into the
+// table. label names the targets in the results.
+func (ui *HostScannerUI) startScan(label string, targets *network.RangeSet) {
+	ctx, cancel := context.WithCancel(context.Background())
+	ui.cancelScan = cancel
 	ui.isScanning = true
-	ui.scanButton.SetLabel("⏳ Scanning...")
+	ui.scanButton.SetLabel("⏹ Stop Scan")
 	ui.scanButton.SetBackgroundColor(tcell.ColorOrange)
 	ui.updateProgressBar("Initializing scan...", 0)
 
-	// Parse IP range
-	ipr, err := network.ParseIPRange(ipRange)
-	if err != nil {
-		ui.showModernError(fmt.Sprintf("Invalid IP range: %v", err))
-		ui.resetScanButton()
-		return
+	// Clear previous results. Offline hosts are only kept while they are
+	// shown, so memory does not grow with the size of the range.
+	ui.hosts = nil
+	ui.changes = nil
+	ui.offlineKept = ui.showInactive.IsChecked()
+	keepOffline := ui.offlineKept
+	ui.clearTable()
+
+	// Rows are streamed into the table while the scan runs and put in
+	// order with each progress update.
+	showHost := func(host scanner.Host) {
+		if !host.IsAlive && !keepOffline {
+			return
+		}
+		host = trimOffline(host)
+		ui.app.QueueUpdateDraw(func() {
+			ui.hosts = append(ui.hosts, host)
+			if ui.hostVisible(host) {
+				ui.addHostRow(host)
+				ui.tableStale = true
+			}
+		})
 	}
 
-	// Clear previous results
-	ui.clearTable()
+	showProgress := func(p scanner.Progress) {
+		ui.app.QueueUpdateDraw(func() {
+			if ui.tableStale {
+				ui.renderTable()
+			}
+			ui.updateScanProgress(p)
+		})
+	}
 
 	// Start scanning in goroutine
+	settings := ui.activeProfile().Apply(ui.config.Settings)
 	go func() {
-		ips := ipr.GenerateIPs()
 		ui.app.QueueUpdateDraw(func() {
-			ui.updateProgressBar(fmt.Sprintf("Scanning %d hosts...", len(ips)), 25)
+			ui.updateScanProgress(scanner.Progress{Total: targets.Len()})
 		})
 
-		result := scanner.ScanNetwork(ips, time.Second, 100)
+		opts := append(discoveryOptions(targets.Span(), settings),
+			scanner.WithHostFunc(showHost),
+			scanner.WithProgressFunc(250*time.Millisecond, showProgress),
+			scanner.WithOfflineHosts(keepOffline))
+
+		result := scanner.ScanAddrs(ctx, targets.Addrs(), targets.Len(), settings.Timeout, settings.Workers, opts...)
+		result.NetworkRange = label
+		for i := range result.Hosts {
+			result.Hosts[i] = trimOffline(result.Hosts[i])
+		}
+		inventoryErr := recordInventory(result)
+		var store *inventory.Store
+		if inventoryErr == nil {
+			store = openInventory()
+		}
 
 		ui.app.QueueUpdateDraw(func() {
+			if inventoryErr == nil {
+				ui.inventory = store
+			}
 			ui.scanResults = result
-			ui.displayModernResults(result, ipRange)
+			ui.compareWithBaseline()
+			ui.displayModernResults(result, label)
 			ui.updateInfoPanel()
 			ui.resetScanButton()
+			if inventoryErr != nil {
+				ui.showModernError(fmt.Sprintf("Failed to update inventory: %v", inventoryErr))
+			}
+			if result.Cancelled {
+				ui.updateProgressBar(fmt.Sprintf("Scan cancelled after %d hosts", len(result.Hosts)),
+					ui.progress.Percent())
+				return
+			}
 			ui.updateProgressBar("Scan completed!", 100)
 		})
 	}()
 }
 
+// trimOffline drops the probe outcomes and error of an offline host, which
+// the table does not show, to keep large scans small.
+func trimOffline(host scanner.Host) scanner.Host {
+	if !host.IsAlive {
+		host.Probes = nil
+		host.Error = nil
+	}
+	return host
+}
+
 func (ui *HostScannerUI) autoDetectNetwork() {
 	localNetwork, err := network.GetLocalNetworkRange()
 	if err != nil {
@@ -340,6 +633,10 @@ func (ui *HostScannerUI) autoDetectNetwork() {
 }
 
 func (ui *HostScannerUI) resetScanButton() {
+	if ui.cancelScan != nil {
+		ui.cancelScan()
+		ui.cancelScan = nil
+	}
 	ui.isScanning = false
 	ui.scanButton.SetLabel("🚀 Start Scan")
 	ui.scanButton.SetBackgroundColor(tcell.ColorLightGreen)
@@ -362,105 +659,663 @@ func (ui *HostScannerUI) updateProgressBar(message string, progress int) {
 	ui.progressBar.SetText(fmt.Sprintf("[#ffffff]%s\n%s [#00ff88]%d%%", message, progressBar.String(), progress))
 }
 
+// updateScanProgress refreshes the progress bar and the info panel from
+// the latest progress report of a running scan.
+func (ui *HostScannerUI) updateScanProgress(p scanner.Progress) {
+	ui.progress = p
+
+	eta := "--"
+	if p.Done > 0 {
+		eta = p.ETA().Round(time.Second).String()
+	}
+	ui.updateProgressBar(fmt.Sprintf("%d/%d • %.0f hosts/s • ETA %s", p.Done, p.Total, p.Rate(), eta),
+		p.Percent())
+
+	info := fmt.Sprintf(`[#00ff88::b]⏳ Scan in Progress
+
+[#ffffff::b]Probed:[#ffffff] %d / %d
+[#00ff88::b]Active Hosts:[#ffffff] %d
+[#ffffff::b]Speed:[#ffffff] %.1f hosts/s
+
+[#ffffff::b]Elapsed:[#ffffff] %v
+[#ffffff::b]Remaining:[#ffffff] %s`,
+		p.Done, p.Total,
+		p.Alive,
+		p.Rate(),
+		p.Elapsed.Truncate(time.Second),
+		eta)
+
+	ui.infoPanel.SetText(info)
+}
+
 func (ui *HostScannerUI) clearTable() {
 	ui.table.Clear()
 	ui.setupModernTable()
 }
 
 func (ui *HostScannerUI) displayModernResults(result *scanner.ScanResult, ipRange string) {
-	showInactive := ui.showInactive.IsChecked()
+	ui.hosts = result.Hosts
+	ui.renderTable()
+	ui.table.Select(1, 0).ScrollToBeginning()
 
-	row := 1
-	for _, host := range result.Hosts {
-		if !host.IsAlive && !showInactive {
-			continue
+	// Update content area title with modern styling
+	ui.contentArea.SetTitle(fmt.Sprintf(" 📋 Network Devices - %d Active / %d Total ",
+		result.AliveHosts, result.TotalHosts))
+}
+
+// renderTable rebuilds the table from ui.hosts, applying the offline
+// toggle, the filter and the sort order.
+func (ui *HostScannerUI) renderTable() {
+	rows := make([]scanner.Host, 0, len(ui.hosts))
+	shown := make(map[string]bool)
+	for _, host := range ui.hosts {
+		if ui.hostVisible(host) {
+			rows = append(rows, host)
+			shown[host.IP.String()] = true
 		}
+	}
 
-		// Modern status indicators with colors
-		var status string
-		var statusColor tcell.Color
-		if host.IsAlive {
-			status = "🟢 Online"
-			statusColor = tcell.ColorGreen
-		} else {
-			status = "🔴 Offline"
-			statusColor = tcell.ColorRed
+	// Hosts that disappeared since the baseline get a row of their own
+	if ui.changes != nil {
+		for _, d := range ui.changes.Hosts {
+			if d.Has(diff.HostRemoved) && !shown[d.IP.String()] {
+				gone := *d.Old
+				gone.IsAlive = false
+				if ui.matchesFilter(gone) {
+					rows = append(rows, gone)
+				}
+			}
 		}
+	}
+
+	slices.SortStableFunc(rows, ui.compareHosts)
+
+	ui.clearTable()
+	for _, host := range rows {
+		ui.addHostRow(host)
+	}
+	ui.tableStale = false
+}
 
-		hostname := host.Hostname
-		if hostname == "" {
-			hostname = "[#666666]Unknown"
+// hostVisible reports whether host passes the table's view options.
+func (ui *HostScannerUI) hostVisible(host scanner.Host) bool {
+	if !host.IsAlive && !ui.showInactive.IsChecked() {
+		return false
+	}
+	if ui.hideUnknown.IsChecked() && knownVendor(host.Vendor) == "" {
+		return false
+	}
+	return ui.matchesFilter(host)
+}
+
+// matchesFilter reports whether the IP address, hostname, MAC address,
+// vendor or label of host contains the filter text, ignoring case.
+func (ui *HostScannerUI) matchesFilter(host scanner.Host) bool {
+	filter := strings.ToLower(strings.TrimSpace(ui.filterInput.GetText()))
+	if filter == "" {
+		return true
+	}
+
+	for _, field := range []string{host.IP.String(), host.Hostname, host.MAC, host.Vendor, ui.hostLabel(host)} {
+		if strings.Contains(strings.ToLower(field), filter) {
+			return true
 		}
+	}
+	return false
+}
+
+// sortBy sorts the table by col, reversing the order if it is already
+// sorted by that column.
+func (ui *HostScannerUI) sortBy(col int) {
+	if col < colStatus || col > colLabel {
+		return
+	}
+
+	if col == ui.sortColumn {
+		ui.sortDesc = !ui.sortDesc
+	} else {
+		ui.sortColumn = col
+		ui.sortDesc = false
+	}
+	ui.renderTable()
+	ui.table.Select(1, 0).ScrollToBeginning()
+}
 
-		mac := host.MAC
-		if mac == "" {
-			mac = "[#666666]Unknown"
+// compareHosts orders hosts by the sort column. Missing values sort after
+// present ones and ties are broken by IP address.
+func (ui *HostScannerUI) compareHosts(a, b scanner.Host) int {
+	byText := func(x, y string) int {
+		if (x == "") != (y == "") {
+			return cmp.Compare(y, x) // the empty one last
 		}
+		return cmp.Compare(strings.ToLower(x), strings.ToLower(y))
+	}
+
+	var c int
+	switch ui.sortColumn {
+	case colStatus:
+		c = cmp.Compare(statusRank(a), statusRank(b))
+	case colHostname:
+		c = byText(a.Hostname, b.Hostname)
+	case colMAC:
+		c = byText(a.MAC, b.MAC)
+	case colVendor:
+		c = byText(knownVendor(a.Vendor), knownVendor(b.Vendor))
+	case colLatency:
+		c = cmp.Compare(latencyKey(a), latencyKey(b))
+	case colLabel:
+		c = byText(ui.hostLabel(a), ui.hostLabel(b))
+	}
+	if ui.sortDesc {
+		c = -c
+	}
+	if c != 0 {
+		return c
+	}
+
+	c = bytes.Compare(a.IP.To16(), b.IP.To16())
+	if ui.sortColumn == colIP && ui.sortDesc {
+		c = -c
+	}
+	return c
+}
 
-		vendor := host.Vendor
-		if vendor == "" {
-			vendor = "[#666666]Unknown"
+// statusRank orders online hosts before offline ones.
+func statusRank(host scanner.Host) int {
+	if host.IsAlive {
+		return 0
+	}
+	return 1
+}
+
+// latencyKey returns the latency to sort host by, with offline hosts last.
+func latencyKey(host scanner.Host) time.Duration {
+	if !host.IsAlive {
+		return time.Duration(1<<63 - 1)
+	}
+	return host.Latency
+}
+
+// knownVendor returns vendor, or "" if it is unknown.
+func knownVendor(vendor string) string {
+	if vendor == "Unknown" {
+		return ""
+	}
+	return vendor
+}
+
+// addHostRow appends host as a new row at the bottom of the table.
+func (ui *HostScannerUI) addHostRow(host scanner.Host) {
+	row := ui.table.GetRowCount()
+
+	// Modern status indicators with colors
+	var status string
+	var statusColor tcell.Color
+	if host.IsAlive {
+		status = "🟢 Online"
+		statusColor = tcell.ColorGreen
+	} else {
+		status = "🔴 Offline"
+		statusColor = tcell.ColorRed
+	}
+	if ui.changes != nil {
+		if d, ok := ui.changes.Lookup(host.IP); ok && d.Has(diff.HostRemoved) {
+			status = "⚪ Gone"
+			statusColor = tcell.ColorLightGray
 		}
+	}
 
-		var latency string
-		if !host.IsAlive {
-			latency = "[#666666]N/A"
+	hostname := host.Hostname
+	if hostname == "" {
+		hostname = "[#666666]Unknown"
+	}
+
+	mac := host.MAC
+	if mac == "" {
+		mac = "[#666666]Unknown"
+	}
+
+	vendor := host.Vendor
+	if vendor == "" {
+		vendor = "[#666666]Unknown"
+	}
+
+	var latency string
+	if !host.IsAlive {
+		latency = "[#666666]N/A"
+	} else {
+		// Color code latency
+		latencyMs := float64(host.Latency.Nanoseconds()) / 1000000
+		if latencyMs < 10 {
+			latency = fmt.Sprintf("[#00ff88]%.1fms", latencyMs)
+		} else if latencyMs < 50 {
+			latency = fmt.Sprintf("[#ffaa00]%.1fms", latencyMs)
 		} else {
-			// Color code latency
-			latencyMs := float64(host.Latency.Nanoseconds()) / 1000000
-			if latencyMs < 10 {
-				latency = fmt.Sprintf("[#00ff88]%.1fms", latencyMs)
-			} else if latencyMs < 50 {
-				latency = fmt.Sprintf("[#ffaa00]%.1fms", latencyMs)
-			} else {
-				latency = fmt.Sprintf("[#ff4444]%.1fms", latencyMs)
+			latency = fmt.Sprintf("[#ff4444]%.1fms", latencyMs)
+		}
+	}
+
+	// Create cells with modern styling and responsive expansion
+	ui.table.SetCell(row, colStatus, tview.NewTableCell(status).
+		SetAlign(tview.AlignCenter).
+		SetTextColor(statusColor).
+		SetExpansion(0))
+
+	ui.table.SetCell(row, colIP, tview.NewTableCell(host.IP.String()).
+		SetAlign(tview.AlignLeft).
+		SetTextColor(tcell.ColorLightBlue).
+		SetExpansion(0).
+		SetReference(host))
+
+	ui.table.SetCell(row, colHostname, tview.NewTableCell(hostname).
+		SetAlign(tview.AlignLeft).
+		SetTextColor(tcell.ColorWhite).
+		SetExpansion(1))
+
+	ui.table.SetCell(row, colMAC, tview.NewTableCell(mac).
+		SetAlign(tview.AlignLeft).
+		SetTextColor(tcell.ColorLightGray).
+		SetExpansion(0))
+
+	ui.table.SetCell(row, colVendor, tview.NewTableCell(vendor).
+		SetAlign(tview.AlignLeft).
+		SetTextColor(tcell.ColorLightYellow).
+		SetExpansion(1))
+
+	ui.table.SetCell(row, colLatency, tview.NewTableCell(latency).
+		SetAlign(tview.AlignRight).
+		SetTextColor(tcell.ColorWhite).
+		SetExpansion(0))
+
+	ui.table.SetCell(row, colLabel, tview.NewTableCell(ui.hostLabel(host)).
+		SetAlign(tview.AlignLeft).
+		SetTextColor(tcell.ColorLightGreen).
+		SetExpansion(1))
+
+	// Alternate row colors for better readability
+	if row%2 == 0 {
+		for col := 0; col < ui.table.GetColumnCount(); col++ {
+			ui.table.GetCell(row, col).SetBackgroundColor(tcell.ColorDarkSlateGray)
+		}
+	}
+
+	// Rows that changed since the baseline scan stand out
+	if ui.changes != nil {
+		if d, ok := ui.changes.Lookup(host.IP); ok {
+			for col := 0; col < ui.table.GetColumnCount(); col++ {
+				ui.table.GetCell(row, col).SetBackgroundColor(changeColor(d))
+			}
+		}
+	}
+}
+
+// changeColor returns the row background for a host that changed: green
+// for new hosts, grey for disappeared ones, red for MAC changes and brown
+// for everything else.
+func changeColor(d diff.HostDiff) tcell.Color {
+	switch {
+	case d.Has(diff.HostAdded):
+		return tcell.ColorDarkGreen
+	case d.Has(diff.HostRemoved):
+		return tcell.ColorDimGray
+	case d.Has(diff.MACChanged):
+		return tcell.ColorDarkRed
+	default:
+		return tcell.ColorSaddleBrown
+	}
+}
+
+// compareWithBaseline compares the current results with the baseline
+// scan, if both are set.
+func (ui *HostScannerUI) compareWithBaseline() {
+	ui.changes = nil
+	if ui.baseline != nil && ui.scanResults != nil {
+		ui.changes = diff.Compare(ui.baseline, ui.scanResults, diff.DefaultOptions)
+	}
+}
+
+// showExportForm asks for a file name and format and saves the current
+// results. Offline hosts are included when they are shown in the table.
+// NDJSON is written in one go here; only the scan command streams it.
+func (ui *HostScannerUI) showExportForm() {
+	if ui.scanResults == nil {
+		ui.showModernError("Nothing to export yet, run a scan first")
+		return
+	}
+
+	formats := []string{"json", "ndjson", "csv", "tsv", "xml"}
+	selected := max(slices.Index(formats, ui.activeProfile().Format), 0)
+	format := formats[selected]
+	path := fmt.Sprintf("hostscanner-%s.%s", time.Now().Format("20060102-150405"), format)
+
+	form := tview.NewForm()
+	form.AddInputField("File", path, 40, nil, func(text string) {
+		path = text
+	}).
+		AddDropDown("Format", formats, selected, func(option string, _ int) {
+			format = option
+		}).
+		AddButton("Save", func() {
+			out := outputOptions{format: format, all: ui.showInactive.IsChecked()}
+			if err := exportResult(path, ui.scanResults, out); err != nil {
+				ui.showModernError(fmt.Sprintf("Export failed: %v", err))
+				return
+			}
+			ui.pages.RemovePage("export")
+			ui.updateProgressBar(fmt.Sprintf("Exported results to %s", path), 100)
+		}).
+		AddButton("Cancel", func() {
+			ui.pages.RemovePage("export")
+		})
+	form.SetBorder(true).
+		SetBorderColor(tcell.ColorDarkSlateGray).
+		SetTitle(" 💾 Export Results ").
+		SetTitleColor(tcell.ColorLightYellow)
+
+	ui.pages.AddPage("export", centered(form, 60, 9), true, true)
+}
+
+// openInventory reads the inventory used for the label column, or returns
+// nil if it cannot be read.
+func openInventory() *inventory.Store {
+	path, err := inventory.DefaultPath()
+	if err != nil {
+		return nil
+	}
+	store, err := inventory.Open(path)
+	if err != nil {
+		return nil
+	}
+
+	return store
+}
+
+// showLabelForm edits the labels of host.
+// Labels are stored in the inventory, so only hosts seen by a scan can be
+// labelled.
+func (ui *HostScannerUI) showLabelForm(host scanner.Host) {
+	path, err := inventory.DefaultPath()
+	if err != nil {
+		ui.showModernError(fmt.Sprintf("Cannot locate inventory: %v", err))
+		return
+	}
+	// Read the file so the form shows labels saved by another instance
+	store, err := inventory.Open(path)
+	if err != nil {
+		ui.showModernError(fmt.Sprintf("Cannot read inventory: %v", err))
+		return
+	}
+	device, ok := store.Lookup(host)
+	if !ok {
+		ui.showModernError(fmt.Sprintf("%s is not in the inventory yet, scan it first", host.IP))
+		return
+	}
+
+	labels := device.Labels
+	tags := strings.Join(labels.Tags, ", ")
+
+	form := tview.NewForm()
+	form.AddInputField("Name", labels.Name, 40, nil, func(text string) {
+		labels.Name = text
+	}).
+		AddInputField("Owner", labels.Owner, 40, nil, func(text string) {
+			labels.Owner = text
+		}).
+		AddInputField("Tags", tags, 40, nil, func(text string) {
+			tags = text
+		}).
+		AddTextArea("Notes", labels.Notes, 40, 4, 0, func(text string) {
+			labels.Notes = text
+		}).
+		AddButton("Save", func() {
+			labels.Tags = splitTags(tags)
+
+			// Apply the labels to the current file, which a scan may have
+			// updated while the form was open
+			var saved *inventory.Store
+			err := inventory.Edit(path, func(s *inventory.Store) error {
+				saved = s
+				d, ok := s.Lookup(host)
+				if !ok {
+					return fmt.Errorf("%s is no longer in the inventory", host.IP)
+				}
+				return s.SetLabels(d.Key(), labels)
+			})
+			if err != nil {
+				ui.showModernError(fmt.Sprintf("Failed to save labels: %v", err))
+				return
 			}
+			ui.inventory = saved
+			ui.pages.RemovePage("labels")
+			ui.renderTable()
+		}).
+		AddButton("Cancel", func() {
+			ui.pages.RemovePage("labels")
+		})
+	form.SetBorder(true).
+		SetBorderColor(tcell.ColorDarkSlateGray).
+		SetTitle(fmt.Sprintf(" 🏷️ Label %s ", device.Key())).
+		SetTitleColor(tcell.ColorLightGreen)
+
+	ui.pages.AddPage("labels", centered(form, 60, 15), true, true)
+}
+
+// hostLabel returns the label column of host.
+func (ui *HostScannerUI) hostLabel(host scanner.Host) string {
+	if ui.inventory == nil {
+		return ""
+	}
+	if d, ok := ui.inventory.Lookup(host); ok {
+		return labelText(d.Labels)
+	}
+	return ""
+}
+
+// labelText returns what the label column shows for a device: its name,
+// or its tags if it has no name.
+func labelText(labels inventory.Labels) string {
+	if labels.Name != "" {
+		return labels.Name
+	}
+	return strings.Join(labels.Tags, ", ")
+}
+
+// splitTags parses a comma-separated list of tags.
+func splitTags(s string) []string {
+	var tags []string
+	for _, tag := range strings.Split(s, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
 		}
+	}
+	return tags
+}
+
+// showSettingsForm edits the scan settings and saves them to the config
+// file, where they are loaded from at startup.
+func (ui *HostScannerUI) showSettingsForm() {
+	settings := ui.config.Settings
+	timeout := settings.Timeout.String()
+	workers := strconv.Itoa(settings.Workers)
+	retries := strconv.Itoa(settings.Retries)
+	ports := config.FormatPorts(tcpPortsOf(settings))
+	methods := make(map[string]bool)
+	for _, m := range settings.Methods {
+		methods[m] = true
+	}
+
+	form := tview.NewForm()
+	form.AddInputField("Timeout per host", timeout, 10, nil, func(text string) {
+		timeout = text
+	}).
+		AddInputField("Workers", workers, 10, tview.InputFieldInteger, func(text string) {
+			workers = text
+		}).
+		AddInputField("Retries", retries, 10, tview.InputFieldInteger, func(text string) {
+			retries = text
+		}).
+		AddInputField("TCP ports", ports, 24, nil, func(text string) {
+			ports = text
+		})
+	for _, m := range config.Methods {
+		form.AddCheckbox(strings.ToUpper(m)+" probes", methods[m], func(checked bool) {
+			methods[m] = checked
+		})
+	}
+	form.AddCheckbox("Resolve host names", settings.DNS, func(checked bool) {
+		settings.DNS = checked
+	}).
+		AddButton("Save", func() {
+			var err error
+			if settings.Timeout, err = time.ParseDuration(timeout); err != nil {
+				ui.showModernError(fmt.Sprintf("Invalid timeout %q, use e.g. 500ms or 2s", timeout))
+				return
+			}
+			settings.Workers, _ = strconv.Atoi(workers)
+			settings.Retries, _ = strconv.Atoi(retries)
+			if settings.TCPPorts, err = config.ParsePorts(ports); err != nil {
+				ui.showModernError(err.Error())
+				return
+			}
+			settings.Methods = nil
+			for _, m := range config.Methods {
+				if methods[m] {
+					settings.Methods = append(settings.Methods, m)
+				}
+			}
+			if err := settings.Validate(); err != nil {
+				ui.showModernError(err.Error())
+				return
+			}
 
-		// Create cells with modern styling and responsive expansion
-		ui.table.SetCell(row, 0, tview.NewTableCell(status).
-			SetAlign(tview.AlignCenter).
-			SetTextColor(statusColor).
-			SetExpansion(0))
-
-		ui.table.SetCell(row, 1, tview.NewTableCell(host.IP.String()).
-			SetAlign(tview.AlignLeft).
-			SetTextColor(tcell.ColorLightBlue).
-			SetExpansion(0))
-
-		ui.table.SetCell(row, 2, tview.NewTableCell(hostname).
-			SetAlign(tview.AlignLeft).
-			SetTextColor(tcell.ColorWhite).
-			SetExpansion(1))
-
-		ui.table.SetCell(row, 3, tview.NewTableCell(mac).
-			SetAlign(tview.AlignLeft).
-			SetTextColor(tcell.ColorLightGray).
-			SetExpansion(0))
-
-		ui.table.SetCell(row, 4, tview.NewTableCell(vendor).
-			SetAlign(tview.AlignLeft).
-			SetTextColor(tcell.ColorLightYellow).
-			SetExpansion(1))
-
-		ui.table.SetCell(row, 5, tview.NewTableCell(latency).
-			SetAlign(tview.AlignRight).
-			SetTextColor(tcell.ColorWhite).
-			SetExpansion(0))
-
-		// Alternate row colors for better readability
-		if row%2 == 0 {
-			for col := 0; col < 6; col++ {
-				ui.table.GetCell(row, col).SetBackgroundColor(tcell.ColorDarkSlateGray)
+			// Only the settings are written, so profiles in a file that
+			// failed to load are not lost
+			path, err := config.DefaultPath()
+			if err == nil {
+				err = config.SaveSettings(path, settings)
+			}
+			if err != nil {
+				ui.showModernError(fmt.Sprintf("Failed to save settings: %v", err))
+				return
 			}
+			cfg := *ui.config
+			cfg.Settings = settings
+			ui.config = &cfg
+			ui.pages.RemovePage("settings")
+			ui.updateProgressBar("Settings saved", 0)
+		}).
+		AddButton("Cancel", func() {
+			ui.pages.RemovePage("settings")
+		})
+	form.SetBorder(true).
+		SetBorderColor(tcell.ColorDarkSlateGray).
+		SetTitle(" ⚙️ Scan Settings ").
+		SetTitleColor(tcell.ColorLightBlue)
+
+	ui.pages.AddPage("settings", centered(form, 50, 21), true, true)
+}
+
+// showImportForm asks for a results file saved as hostscanner JSON or
+// NDJSON, or as nmap XML. The hosts in it can be shown in the table, used
+// as the baseline that later scans are compared with, or scanned again.
+func (ui *HostScannerUI) showImportForm() {
+	if ui.isScanning {
+		ui.showModernError("Stop the running scan before importing results")
+		return
+	}
+
+	path := ""
+	load := func() *scanner.ScanResult {
+		result, err := report.ReadFile(path)
+		if err != nil {
+			ui.showModernError(fmt.Sprintf("Import failed: %v", err))
+			return nil
+		}
+		if result.NetworkRange == "" {
+			result.NetworkRange = path
 		}
+		return result
+	}
 
-		row++
+	form := tview.NewForm()
+	form.AddInputField("File", path, 40, nil, func(text string) {
+		path = text
+	}).
+		AddButton("Open", func() {
+			result := load()
+			if result == nil {
+				return
+			}
+			ui.pages.RemovePage("import")
+			ui.scanResults = result
+			ui.offlineKept = true
+			ui.compareWithBaseline()
+			ui.displayModernResults(result, result.NetworkRange)
+			ui.updateInfoPanel()
+			ui.updateProgressBar(fmt.Sprintf("Imported %d hosts from %s", len(result.Hosts), path), 100)
+		}).
+		AddButton("Compare", func() {
+			result := load()
+			if result == nil {
+				return
+			}
+			ui.pages.RemovePage("import")
+			ui.baseline = result
+			ui.compareWithBaseline()
+			if ui.scanResults != nil {
+				ui.displayModernResults(ui.scanResults, ui.scanResults.NetworkRange)
+				ui.updateInfoPanel()
+			}
+			ui.updateProgressBar(fmt.Sprintf("Comparing scans with %s", path), 100)
+		}).
+		AddButton("Rescan", func() {
+			result := load()
+			if result == nil {
+				return
+			}
+			targets, err := importedTargets(result, ui.activeProfile().Exclude)
+			if err != nil {
+				ui.showModernError(fmt.Sprintf("Cannot rescan %s: %v", path, err))
+				return
+			}
+			ui.pages.RemovePage("import")
+			ui.startScan(result.NetworkRange, targets)
+		}).
+		AddButton("Cancel", func() {
+			ui.pages.RemovePage("import")
+		})
+	form.SetBorder(true).
+		SetBorderColor(tcell.ColorDarkSlateGray).
+		SetTitle(" 📂 Import Results ").
+		SetTitleColor(tcell.ColorLightYellow)
+
+	ui.pages.AddPage("import", centered(form, 64, 7), true, true)
+}
+
+// exportResult writes result to the file at path.
+func exportResult(path string, result *scanner.ScanResult, out outputOptions) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
 	}
 
-	// Update content area title with modern styling
-	ui.contentArea.SetTitle(fmt.Sprintf(" 📋 Network Devices - %d Active / %d Total ",
-		result.AliveHosts, result.TotalHosts))
+	if err := writeResult(f, result, out); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// centered returns p wrapped in a layout that centers it on the screen at
+// the given size.
+func centered(p tview.Primitive, width, height int) tview.Primitive {
+	return tview.NewFlex().
+		AddItem(nil, 0, 1, false).
+		AddItem(tview.NewFlex().SetDirection(tview.FlexRow).
+			AddItem(nil, 0, 1, false).
+			AddItem(p, height, 0, true).
+			AddItem(nil, 0, 1, false), width, 0, true).
+		AddItem(nil, 0, 1, false)
 }
 
 func (ui *HostScannerUI) showModernError(message string) {
@@ -472,7 +1327,7 @@ func (ui *HostScannerUI) showModernError(message string) {
 		SetButtonBackgroundColor(tcell.ColorRed).
 		SetButtonTextColor(tcell.ColorWhite).
 		SetDoneFunc(func(buttonIndex int, buttonLabel string) {
-			ui.pages.SwitchToPage("main")
+			ui.pages.RemovePage("error")
 		})
 
 	ui.pages.AddPage("error", modal, true, true)
@@ -1,9 +1,13 @@
 package network
 
 import (
+	"encoding/binary"
 	"errors"
 	"fmt"
+	"iter"
+	"math"
 	"net"
+	"net/netip"
 	"strings"
 )
 
@@ -13,8 +17,14 @@ var (
 	ErrInvalidCIDR      = errors.New("invalid CIDR notation")
 	ErrInvalidRange     = errors.New("invalid IP range format")
 	ErrNoLocalNetwork   = errors.New("no local network found")
+	ErrRangeTooLarge    = errors.New("IP range too large")
 )
 
+// MaxRangeSize is the largest number of addresses an IPv6 range may hold.
+// It admits a /104; larger prefixes such as a /64 cannot be scanned address
+// by address. IPv4 ranges are not limited.
+const MaxRangeSize = 1 << 24
+
 // IPRange represents an IP range
 type IPRange struct {
 	StartIP net.IP
@@ -61,11 +71,19 @@ func parseCIDR(cidr string) (*IPRange, error) {
 	for i := 0; i < len(startIP); i++ {
 		endIP[i] = startIP[i] | ^ipNet.Mask[i]
 	}
-	
-	return &IPRange{
+
+	r := &IPRange{
 		StartIP: startIP,
 		EndIP:   endIP,
-	}, nil
+	}
+	start, end, _ := r.bounds()
+	if _, ok := rangeSize(start, end); !ok {
+		ones, bits := ipNet.Mask.Size()
+		return nil, fmt.Errorf("%w: %s holds 2^%d addresses, at most %d can be scanned",
+			ErrRangeTooLarge, cidr, bits-ones, MaxRangeSize)
+	}
+
+	return r, nil
 }
 
 // parseRange parses range notation (e.g., 192.168.1.1-192.168.1.255).
@@ -81,49 +99,129 @@ func parseRange(rangeStr string) (*IPRange, error) {
 	if startIP == nil || endIP == nil {
 		return nil, fmt.Errorf("%w: invalid IP addresses in range %s", ErrInvalidRange, rangeStr)
 	}
-	
-	return &IPRange{
+
+	r := &IPRange{
 		StartIP: startIP,
 		EndIP:   endIP,
-	}, nil
+	}
+	start, end, ok := r.bounds()
+	if !ok {
+		return nil, fmt.Errorf("%w: %s must go from a lower to a higher address of the same family",
+			ErrInvalidRange, rangeStr)
+	}
+	if _, ok := rangeSize(start, end); !ok {
+		return nil, fmt.Errorf("%w: %s holds more than %d addresses", ErrRangeTooLarge, rangeStr, MaxRangeSize)
+	}
+
+	return r, nil
 }
 
-// GenerateIPs generates all IPs in the range.
-// It returns an empty slice for IPv6 addresses or invalid IP ranges.
+// GenerateIPs generates all IPs in the range, in ascending order.
+// It returns nil for invalid ranges and IPv6 ranges larger than
+// MaxRangeSize.
+// Use Addrs to go through a range without holding all of it in memory.
 func (r *IPRange) GenerateIPs() []net.IP {
-	// Convert to 4-byte representation for easier arithmetic
-	startIP := r.StartIP.To4()
-	endIP := r.EndIP.To4()
-	
-	if startIP == nil || endIP == nil {
-		// Handle IPv6 or invalid IPs
+	start, end, ok := r.bounds()
+	if !ok {
 		return nil
 	}
-	
-	// Convert to uint32 for easier arithmetic
-	start := ipToUint32(startIP)
-	end := ipToUint32(endIP)
-	
+	size, ok := rangeSize(start, end)
+	if !ok {
+		return nil
+	}
+
 	// Pre-allocate slice with known capacity for better performance
-	capacity := int(end - start + 1)
-	ips := make([]net.IP, 0, capacity)
-	
-	for i := start; i <= end; i++ {
-		ips = append(ips, uint32ToIP(i))
+	ips := make([]net.IP, 0, size)
+	for addr := range r.Addrs() {
+		ips = append(ips, AddrIP(addr))
 	}
-	
+
 	return ips
 }
 
-// ipToUint32 converts IPv4 to uint32
-func ipToUint32(ip net.IP) uint32 {
-	ip = ip.To4()
-	return uint32(ip[0])<<24 + uint32(ip[1])<<16 + uint32(ip[2])<<8 + uint32(ip[3])
+// Addrs returns an iterator over the addresses of the range in ascending
+// order. Addresses are produced as they are consumed, so ranges of any
+// size can be iterated. It yields nothing for invalid ranges.
+func (r *IPRange) Addrs() iter.Seq[netip.Addr] {
+	return func(yield func(netip.Addr) bool) {
+		if start, end, ok := r.bounds(); ok {
+			yieldRange(start, end, yield)
+		}
+	}
+}
+
+// yieldRange calls yield for each address from start to end inclusive
+// until it returns false. It reports whether yield always returned true.
+func yieldRange(start, end netip.Addr, yield func(netip.Addr) bool) bool {
+	for addr := start; ; addr = addr.Next() {
+		if !yield(addr) {
+			return false
+		}
+		if addr == end {
+			return true
+		}
+	}
+}
+
+// AddrIP converts addr to a net.IP in its 16-byte form, as returned by
+// net.ParseIP.
+func AddrIP(addr netip.Addr) net.IP {
+	b := addr.As16()
+	return net.IP(b[:])
+}
+
+// Contains reports whether ip lies within the range.
+func (r *IPRange) Contains(ip net.IP) bool {
+	start, end, ok := r.bounds()
+	if !ok {
+		return false
+	}
+	addr, ok := netip.AddrFromSlice(ip)
+	if !ok {
+		return false
+	}
+	addr = addr.Unmap()
+
+	return addr.Is4() == start.Is4() && addr.Compare(start) >= 0 && addr.Compare(end) <= 0
+}
+
+// bounds returns the first and last address of the range. IPv4-mapped IPv6
+// addresses are treated as IPv4, and ok is false unless both addresses are
+// valid, of the same family and in order.
+func (r *IPRange) bounds() (start, end netip.Addr, ok bool) {
+	start, ok1 := netip.AddrFromSlice(r.StartIP)
+	end, ok2 := netip.AddrFromSlice(r.EndIP)
+	if !ok1 || !ok2 {
+		return netip.Addr{}, netip.Addr{}, false
+	}
+	start, end = start.Unmap(), end.Unmap()
+	if start.Is4() != end.Is4() || start.Compare(end) > 0 {
+		return netip.Addr{}, netip.Addr{}, false
+	}
+
+	return start, end, true
+}
+
+// rangeSize returns the number of addresses from start to end inclusive,
+// or false if an IPv6 range holds more than MaxRangeSize.
+func rangeSize(start, end netip.Addr) (int, bool) {
+	n := countAddrs(start, end)
+	return n, start.Is4() || n <= MaxRangeSize
 }
 
-// uint32ToIP converts uint32 to IPv4
-func uint32ToIP(n uint32) net.IP {
-	return net.IPv4(byte(n>>24), byte(n>>16), byte(n>>8), byte(n))
+// countAddrs returns the number of addresses from start to end inclusive,
+// capped at math.MaxInt.
+func countAddrs(start, end netip.Addr) int {
+	a, b := start.As16(), end.As16()
+	if binary.BigEndian.Uint64(a[:8]) != binary.BigEndian.Uint64(b[:8]) {
+		return math.MaxInt
+	}
+	n := binary.BigEndian.Uint64(b[8:]) - binary.BigEndian.Uint64(a[8:])
+	if n >= math.MaxInt {
+		return math.MaxInt
+	}
+
+	return int(n) + 1
 }
 
 // GetLocalNetworkRange attempts to detect the local network range.
@@ -145,3 +243,55 @@ func GetLocalNetworkRange() (string, error) {
 	
 	return "", ErrNoLocalNetwork
 }
+
+// LocalNetwork is an IPv4 subnet directly connected to an interface.
+type LocalNetwork struct {
+	Interface net.Interface
+	// Network holds the interface's own address and the subnet mask.
+	Network *net.IPNet
+}
+
+// LocalNetworks returns the IPv4 subnets of all interfaces that are up,
+// skipping loopback interfaces.
+func LocalNetworks() ([]LocalNetwork, error) {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get interfaces: %w", err)
+	}
+
+	var networks []LocalNetwork
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			if ipNet, ok := addr.(*net.IPNet); ok && ipNet.IP.To4() != nil {
+				networks = append(networks, LocalNetwork{Interface: iface, Network: ipNet})
+			}
+		}
+	}
+
+	return networks, nil
+}
+
+// LocalNetworkFor returns the directly connected subnet containing every
+// address of r. It returns ErrNoLocalNetwork if there is none.
+func LocalNetworkFor(r *IPRange) (*LocalNetwork, error) {
+	networks, err := LocalNetworks()
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range networks {
+		if networks[i].Network.Contains(r.StartIP) && networks[i].Network.Contains(r.EndIP) {
+			return &networks[i], nil
+		}
+	}
+
+	return nil, ErrNoLocalNetwork
+}
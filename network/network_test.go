@@ -55,6 +55,60 @@ func TestParseIPRange_InvalidCIDR(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestParseIPRange_IPv6CIDR(t *testing.T) {
+	ipRange, err := network.ParseIPRange("2001:db8::/120")
+	assert.NoError(t, err)
+
+	ips := ipRange.GenerateIPs()
+	assert.Equal(t, 256, len(ips))
+	assert.Equal(t, "2001:db8::", ips[0].String())
+	assert.Equal(t, "2001:db8::ff", ips[255].String())
+}
+
+func TestParseIPRange_IPv6Range(t *testing.T) {
+	ipRange, err := network.ParseIPRange("2001:db8::ffff:fffe-2001:db8::1:0:1")
+	assert.NoError(t, err)
+
+	ips := ipRange.GenerateIPs()
+	assert.Equal(t, 4, len(ips))
+	assert.Equal(t, "2001:db8::ffff:ffff", ips[1].String())
+	assert.Equal(t, "2001:db8::1:0:0", ips[2].String())
+}
+
+func TestParseIPRange_TooLarge(t *testing.T) {
+	_, err := network.ParseIPRange("fe80::/64")
+	assert.ErrorIs(t, err, network.ErrRangeTooLarge)
+
+	_, err = network.ParseIPRange("2001:db8::-2001:db8::1:0:0:0")
+	assert.ErrorIs(t, err, network.ErrRangeTooLarge)
+
+	// IPv4 ranges are not limited
+	_, err = network.ParseIPRange("10.0.0.0/7")
+	assert.NoError(t, err)
+
+	_, err = network.ParseIPRange("0.0.0.0-255.255.255.255")
+	assert.NoError(t, err)
+}
+
+func TestParseIPRange_MixedOrReversed(t *testing.T) {
+	_, err := network.ParseIPRange("192.168.1.1-::1")
+	assert.ErrorIs(t, err, network.ErrInvalidRange)
+
+	_, err = network.ParseIPRange("192.168.1.9-192.168.1.1")
+	assert.ErrorIs(t, err, network.ErrInvalidRange)
+}
+
+func TestIPRange_Contains(t *testing.T) {
+	ipRange, err := network.ParseIPRange("192.168.1.0/30")
+	assert.NoError(t, err)
+
+	assert.True(t, ipRange.Contains(net.ParseIP("192.168.1.0")))
+	assert.True(t, ipRange.Contains(net.ParseIP("192.168.1.3")))
+	assert.False(t, ipRange.Contains(net.ParseIP("192.168.1.4")))
+	assert.False(t, ipRange.Contains(net.ParseIP("10.0.0.1")))
+	assert.False(t, ipRange.Contains(net.ParseIP("2001:db8::1")))
+}
+
 func TestGetLocalNetworkRange(t *testing.T) {
 	// Test getting local network range
 	localNetwork, err := network.GetLocalNetworkRange()
@@ -71,3 +125,81 @@ func TestGetLocalNetworkRange(t *testing.T) {
 	_, _, err = net.ParseCIDR(localNetwork)
 	assert.NoError(t, err)
 }
+
+func TestLocalNetworkFor(t *testing.T) {
+	networks, err := network.LocalNetworks()
+	if err != nil || len(networks) == 0 {
+		t.Skipf("No local networks: %v", err)
+	}
+
+	local := networks[0]
+	ipRange, err := network.ParseIPRange(local.Network.IP.String())
+	assert.NoError(t, err)
+
+	found, err := network.LocalNetworkFor(ipRange)
+	assert.NoError(t, err)
+	assert.True(t, found.Network.Contains(local.Network.IP))
+
+	// A documentation range is never on a local link.
+	ipRange, err = network.ParseIPRange("198.51.100.0/24")
+	assert.NoError(t, err)
+	_, err = network.LocalNetworkFor(ipRange)
+	assert.ErrorIs(t, err, network.ErrNoLocalNetwork)
+}
+
+func TestIPRange_Addrs(t *testing.T) {
+	// A /8 is iterated lazily, so stopping early costs nothing
+	ipRange, err := network.ParseIPRange("10.0.0.0/8")
+	assert.NoError(t, err)
+
+	var first []string
+	for addr := range ipRange.Addrs() {
+		first = append(first, addr.String())
+		if len(first) == 3 {
+			break
+		}
+	}
+	assert.Equal(t, []string{"10.0.0.0", "10.0.0.1", "10.0.0.2"}, first)
+}
+
+func TestRangeSet(t *testing.T) {
+	var set network.RangeSet
+	for _, r := range []string{"192.168.1.10-192.168.1.20", "192.168.1.0/28", "192.168.1.21", "2001:db8::/126", "10.0.0.1"} {
+		ipRange, err := network.ParseIPRange(r)
+		assert.NoError(t, err)
+		set.Add(ipRange)
+	}
+	for _, r := range []string{"192.168.1.5-192.168.1.12", "2001:db8::2"} {
+		ipRange, err := network.ParseIPRange(r)
+		assert.NoError(t, err)
+		set.Remove(ipRange)
+	}
+
+	var addrs []string
+	for addr := range set.Addrs() {
+		addrs = append(addrs, addr.String())
+	}
+	assert.Equal(t, []string{
+		"10.0.0.1",
+		"192.168.1.0", "192.168.1.1", "192.168.1.2", "192.168.1.3", "192.168.1.4",
+		"192.168.1.13", "192.168.1.14", "192.168.1.15", "192.168.1.16", "192.168.1.17",
+		"192.168.1.18", "192.168.1.19", "192.168.1.20", "192.168.1.21",
+		"2001:db8::", "2001:db8::1", "2001:db8::3",
+	}, addrs)
+	assert.Equal(t, len(addrs), set.Len())
+
+	span := set.Span()
+	assert.Equal(t, "10.0.0.1", span.StartIP.String())
+	assert.Equal(t, "2001:db8::3", span.EndIP.String())
+}
+
+func TestRangeSet_Large(t *testing.T) {
+	var set network.RangeSet
+	for _, r := range []string{"10.0.0.0/8", "11.0.0.0/8"} {
+		ipRange, err := network.ParseIPRange(r)
+		assert.NoError(t, err)
+		set.Add(ipRange)
+	}
+	assert.Equal(t, 2<<24, set.Len())
+	assert.Nil(t, (&network.RangeSet{}).Span())
+}
@@ -0,0 +1,133 @@
+package network
+
+import (
+	"iter"
+	"math"
+	"net"
+	"net/netip"
+	"slices"
+)
+
+// RangeSet is a set of addresses held as disjoint ranges, so large and
+// overlapping ranges can be combined without listing their addresses.
+// The zero value is an empty set.
+type RangeSet struct {
+	ranges []addrRange // disjoint, not adjacent, in ascending order
+	dirty  bool        // ranges were added since they were last merged
+}
+
+// addrRange is the addresses from start to end inclusive, both of the same
+// family.
+type addrRange struct {
+	start, end netip.Addr
+}
+
+// Add adds the addresses of r to the set. Invalid ranges are ignored.
+func (s *RangeSet) Add(r *IPRange) {
+	start, end, ok := r.bounds()
+	if !ok {
+		return
+	}
+
+	s.ranges = append(s.ranges, addrRange{start, end})
+	s.dirty = true
+}
+
+// merge sorts the ranges and merges overlapping and adjacent ones.
+func (s *RangeSet) merge() {
+	if !s.dirty {
+		return
+	}
+	s.dirty = false
+
+	slices.SortFunc(s.ranges, func(a, b addrRange) int {
+		return a.start.Compare(b.start)
+	})
+	merged := s.ranges[:1]
+	for _, next := range s.ranges[1:] {
+		last := &merged[len(merged)-1]
+		if next.start.Is4() == last.end.Is4() &&
+			(next.start.Compare(last.end) <= 0 || next.start == last.end.Next()) {
+			if next.end.Compare(last.end) > 0 {
+				last.end = next.end
+			}
+			continue
+		}
+		merged = append(merged, next)
+	}
+	s.ranges = merged
+}
+
+// AddIP adds a single address to the set.
+func (s *RangeSet) AddIP(ip net.IP) {
+	s.Add(&IPRange{StartIP: ip, EndIP: ip})
+}
+
+// Remove removes the addresses of r from the set.
+func (s *RangeSet) Remove(r *IPRange) {
+	start, end, ok := r.bounds()
+	if !ok {
+		return
+	}
+
+	s.merge()
+	var kept []addrRange
+	for _, rr := range s.ranges {
+		if rr.start.Is4() != start.Is4() || rr.end.Compare(start) < 0 || rr.start.Compare(end) > 0 {
+			kept = append(kept, rr)
+			continue
+		}
+		if rr.start.Compare(start) < 0 {
+			kept = append(kept, addrRange{rr.start, start.Prev()})
+		}
+		if rr.end.Compare(end) > 0 {
+			kept = append(kept, addrRange{end.Next(), rr.end})
+		}
+	}
+	s.ranges = kept
+}
+
+// Len returns the number of addresses in the set, capped at math.MaxInt.
+func (s *RangeSet) Len() int {
+	s.merge()
+	n := 0
+	for _, r := range s.ranges {
+		c := countAddrs(r.start, r.end)
+		if c > math.MaxInt-n {
+			return math.MaxInt
+		}
+		n += c
+	}
+
+	return n
+}
+
+// Addrs returns an iterator over the addresses of the set in ascending
+// order, IPv4 before IPv6. Addresses are produced as they are consumed.
+func (s *RangeSet) Addrs() iter.Seq[netip.Addr] {
+	s.merge()
+	ranges := s.ranges
+	return func(yield func(netip.Addr) bool) {
+		for _, r := range ranges {
+			if !yieldRange(r.start, r.end, yield) {
+				return
+			}
+		}
+	}
+}
+
+// Span returns the smallest range holding every address of the set, or
+// nil if the set is empty. For a set holding both IPv4 and IPv6 addresses
+// it goes from the lowest IPv4 to the highest IPv6 address and is not a
+// valid range.
+func (s *RangeSet) Span() *IPRange {
+	s.merge()
+	if len(s.ranges) == 0 {
+		return nil
+	}
+
+	return &IPRange{
+		StartIP: AddrIP(s.ranges[0].start),
+		EndIP:   AddrIP(s.ranges[len(s.ranges)-1].end),
+	}
+}
@@ -0,0 +1,83 @@
+//go:build ignore
+
+// gen downloads the IEEE MA-L, MA-M and MA-S registries and writes them as
+// the database embedded in the oui package. Run it with `go generate`.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"hostscanner/internal/atomicfile"
+	"hostscanner/oui"
+)
+
+// registries are the IEEE CSV files, longest assignments last.
+var registries = []string{
+	"https://standards-oui.ieee.org/oui/oui.csv",
+	"https://standards-oui.ieee.org/oui28/mam.csv",
+	"https://standards-oui.ieee.org/oui36/oui36.csv",
+}
+
+func main() {
+	out := flag.String("out", "vendors.gz", "output file")
+	dir := flag.String("dir", "", "read oui.csv, mam.csv and oui36.csv from this directory instead of downloading them")
+	flag.Parse()
+
+	db := oui.New()
+	for _, url := range registries {
+		n, err := importRegistry(db, url, *dir)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("Imported %d assignments from %s\n", n, filepath.Base(url))
+	}
+
+	err := atomicfile.Write(*out, 0o644, func(w io.Writer) error {
+		_, err := db.WriteTo(w)
+		return err
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("Wrote %d assignments to %s\n", db.Len(), *out)
+}
+
+// importRegistry adds the registry at url, or the file of the same name in
+// dir, to db.
+func importRegistry(db *oui.DB, url, dir string) (int, error) {
+	if dir != "" {
+		f, err := os.Open(filepath.Join(dir, filepath.Base(url)))
+		if err != nil {
+			return 0, err
+		}
+		defer f.Close()
+		return db.ImportCSV(f)
+	}
+
+	// Identify the generator to the registry server
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("User-Agent", "hostscanner-oui-generator")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("%s: %s", url, resp.Status)
+	}
+
+	n, err := db.ImportCSV(resp.Body)
+	if err != nil {
+		return n, fmt.Errorf("%s: %w", url, err)
+	}
+	return n, nil
+}
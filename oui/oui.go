@@ -0,0 +1,273 @@
+// Package oui maps MAC addresses to vendor names using the IEEE MA-L,
+// MA-M and MA-S registries.
+//
+// A compact copy of the registries is embedded in the binary and refreshed
+// with `go generate`, which downloads the current IEEE CSV files. It can
+// be replaced at runtime by a database imported from those files, see
+// ImportCSV and UserDBPath.
+package oui
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	_ "embed" // for the embedded vendor database
+)
+
+// Common errors returned by this package.
+var (
+	ErrInvalidPrefix = errors.New("invalid OUI prefix")
+	ErrInvalidCSV    = errors.New("invalid IEEE registry CSV")
+)
+
+// Assignment block sizes in hex digits: MA-S (36 bits), MA-M (28 bits)
+// and MA-L (24 bits), longest first.
+var prefixDigits = []int{9, 7, 6}
+
+// registrationAuthority owns the MA-L blocks the IEEE divides into MA-M and
+// MA-S assignments. It is never the vendor of a device.
+const registrationAuthority = "IEEE Registration Authority"
+
+// registryDigits maps IEEE registry names to their prefix length.
+var registryDigits = map[string]int{
+	"MA-L": 6,
+	"MA-M": 7,
+	"MA-S": 9,
+}
+
+// DB maps MAC address prefixes to vendor names.
+type DB struct {
+	vendors map[string]string // upper-case hex prefix -> vendor
+}
+
+// New returns an empty database.
+func New() *DB {
+	return &DB{vendors: make(map[string]string)}
+}
+
+// Len returns the number of assignments in the database.
+func (db *DB) Len() int {
+	return len(db.vendors)
+}
+
+// Add records vendor as the owner of prefix, given as 6, 7 or 9 hex digits
+// for a 24, 28 or 36 bit assignment.
+func (db *DB) Add(prefix, vendor string) error {
+	prefix = strings.ToUpper(prefix)
+	if !validPrefix(prefix) {
+		return fmt.Errorf("%w: %q", ErrInvalidPrefix, prefix)
+	}
+
+	db.vendors[prefix] = strings.TrimSpace(vendor)
+	return nil
+}
+
+// Lookup returns the vendor of mac using the longest matching assignment.
+// mac may use colons, dashes or dots as separators.
+func (db *DB) Lookup(mac string) (string, bool) {
+	digits := normalizeMAC(mac)
+	for _, n := range prefixDigits {
+		if len(digits) < n {
+			continue
+		}
+		if vendor, ok := db.vendors[digits[:n]]; ok {
+			return vendor, true
+		}
+	}
+
+	return "", false
+}
+
+// ImportCSV adds the assignments of an IEEE registry CSV file (oui.csv,
+// mam.csv or oui36.csv) and returns the number of rows imported. The MA-L
+// blocks held by the IEEE Registration Authority are skipped, so devices
+// in them resolve to the owner of their MA-M or MA-S assignment or to no
+// vendor at all.
+func (db *DB) ImportCSV(r io.Reader) (int, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+
+	header, err := cr.Read()
+	if err != nil {
+		return 0, fmt.Errorf("%w: %w", ErrInvalidCSV, err)
+	}
+	if len(header) < 3 || header[0] != "Registry" {
+		return 0, fmt.Errorf("%w: unexpected header %q", ErrInvalidCSV, header)
+	}
+
+	count := 0
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return count, fmt.Errorf("%w: %w", ErrInvalidCSV, err)
+		}
+		if len(record) < 3 {
+			continue
+		}
+
+		// Registry, Assignment, Organization Name, Organization Address
+		digits, ok := registryDigits[record[0]]
+		if !ok || len(record[1]) != digits || strings.TrimSpace(record[2]) == registrationAuthority {
+			continue
+		}
+		if err := db.Add(record[1], record[2]); err != nil {
+			return count, err
+		}
+		count++
+	}
+
+	return count, nil
+}
+
+// Read loads a database in the compact format written by WriteTo.
+func Read(r io.Reader) (*DB, error) {
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+
+	db := New()
+	s := bufio.NewScanner(zr)
+	for s.Scan() {
+		prefix, vendor, ok := strings.Cut(s.Text(), "\t")
+		if !ok {
+			continue
+		}
+		if err := db.Add(prefix, vendor); err != nil {
+			return nil, err
+		}
+	}
+
+	return db, s.Err()
+}
+
+// WriteTo writes the database in a compact format: gzip-compressed lines
+// of prefix and vendor separated by a tab, sorted by prefix.
+func (db *DB) WriteTo(w io.Writer) (int64, error) {
+	prefixes := make([]string, 0, len(db.vendors))
+	for prefix := range db.vendors {
+		prefixes = append(prefixes, prefix)
+	}
+	sort.Strings(prefixes)
+
+	cw := &countingWriter{w: w}
+	zw, err := gzip.NewWriterLevel(cw, gzip.BestCompression)
+	if err != nil {
+		return 0, err
+	}
+
+	bw := bufio.NewWriter(zw)
+	for _, prefix := range prefixes {
+		bw.WriteString(prefix)
+		bw.WriteByte('\t')
+		bw.WriteString(db.vendors[prefix])
+		bw.WriteByte('\n')
+	}
+	if err := bw.Flush(); err != nil {
+		return cw.n, err
+	}
+	err = zw.Close()
+	return cw.n, err
+}
+
+//go:generate go run gen.go -out vendors.gz
+
+//go:embed vendors.gz
+var embedded []byte
+
+var (
+	defaultOnce sync.Once
+	defaultDB   *DB
+)
+
+// Default returns the database used by Lookup: the file at UserDBPath if
+// one has been imported, otherwise the embedded copy.
+func Default() *DB {
+	defaultOnce.Do(func() {
+		if path, err := UserDBPath(); err == nil {
+			if f, err := os.Open(path); err == nil {
+				defaultDB, err = Read(f)
+				f.Close()
+				if err == nil {
+					return
+				}
+			}
+		}
+
+		db, err := Read(bytes.NewReader(embedded))
+		if err != nil {
+			// The embedded copy is generated by WriteTo and must parse.
+			panic(fmt.Sprintf("oui: corrupt embedded database: %v", err))
+		}
+		defaultDB = db
+	})
+
+	return defaultDB
+}
+
+// Lookup returns the vendor of mac from the default database.
+func Lookup(mac string) (string, bool) {
+	return Default().Lookup(mac)
+}
+
+// UserDBPath returns where an imported database is stored, under the
+// user's configuration directory.
+func UserDBPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "hostscanner", "oui.gz"), nil
+}
+
+// validPrefix reports whether prefix is 6, 7 or 9 upper-case hex digits.
+func validPrefix(prefix string) bool {
+	switch len(prefix) {
+	case 6, 7, 9:
+	default:
+		return false
+	}
+
+	_, err := strconv.ParseUint(prefix, 16, 64)
+	return err == nil && strings.ToUpper(prefix) == prefix
+}
+
+// normalizeMAC strips separators from mac and upper-cases it.
+func normalizeMAC(mac string) string {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(mac) {
+		if (r >= '0' && r <= '9') || (r >= 'A' && r <= 'F') {
+			b.WriteRune(r)
+		}
+	}
+
+	return b.String()
+}
+
+// countingWriter counts the bytes written through it.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
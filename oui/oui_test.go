@@ -0,0 +1,111 @@
+package oui_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"hostscanner/oui"
+)
+
+const registryCSV = `Registry,Assignment,Organization Name,Organization Address
+MA-L,001122,"Example Corp, Inc.",1 Example Way Springfield US 12345
+MA-M,0011223,Medium Block Ltd,2 Example Way Springfield US 12345
+MA-S,001122334,Small Block GmbH,3 Example Way Springfield DE 12345
+`
+
+func TestImportCSV_LongestPrefix(t *testing.T) {
+	db := oui.New()
+	n, err := db.ImportCSV(strings.NewReader(registryCSV))
+	require.NoError(t, err)
+	assert.Equal(t, 3, n)
+
+	tests := []struct {
+		mac    string
+		vendor string
+	}{
+		{"00:11:22:33:44:55", "Small Block GmbH"},
+		{"00:11:22:3F:00:00", "Medium Block Ltd"},
+		{"00-11-22-40-00-00", "Example Corp, Inc."},
+		{"0011.2200.0001", "Example Corp, Inc."},
+	}
+	for _, tt := range tests {
+		vendor, ok := db.Lookup(tt.mac)
+		assert.True(t, ok, tt.mac)
+		assert.Equal(t, tt.vendor, vendor, tt.mac)
+	}
+
+	_, ok := db.Lookup("00:11:33:00:00:00")
+	assert.False(t, ok)
+}
+
+func TestImportCSV_RegistrationAuthority(t *testing.T) {
+	db := oui.New()
+	n, err := db.ImportCSV(strings.NewReader(`Registry,Assignment,Organization Name,Organization Address
+MA-L,70B3D5,IEEE Registration Authority,445 Hoes Lane Piscataway NJ US 08554
+MA-M,70B3D51,Medium Block Ltd,2 Example Way Springfield US 12345
+`))
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	vendor, ok := db.Lookup("70:B3:D5:1A:00:01")
+	assert.True(t, ok)
+	assert.Equal(t, "Medium Block Ltd", vendor)
+
+	// Unassigned parts of the block have no vendor
+	_, ok = db.Lookup("70:B3:D5:F0:00:01")
+	assert.False(t, ok)
+}
+
+func TestImportCSV_Invalid(t *testing.T) {
+	_, err := oui.New().ImportCSV(strings.NewReader("MAC,Vendor\n001122,Example\n"))
+	assert.ErrorIs(t, err, oui.ErrInvalidCSV)
+}
+
+func TestWriteToRead_RoundTrip(t *testing.T) {
+	db := oui.New()
+	_, err := db.ImportCSV(strings.NewReader(registryCSV))
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	_, err = db.WriteTo(&buf)
+	require.NoError(t, err)
+
+	loaded, err := oui.Read(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, db.Len(), loaded.Len())
+
+	vendor, _ := loaded.Lookup("00:11:22:33:44:55")
+	assert.Equal(t, "Small Block GmbH", vendor)
+}
+
+func TestLookup_Embedded(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	// The embedded copy holds the full MA-L registry
+	assert.Greater(t, oui.Default().Len(), 25000)
+
+	tests := []struct {
+		mac    string
+		vendor string
+	}{
+		{"00:50:56:C0:00:01", "VMware, Inc."},
+		{"00:00:0c:07:ac:01", "Cisco Systems, Inc"},
+		{"b8:27:eb:12:34:56", "Raspberry Pi Foundation"},
+		{"00:15:5d:00:04:01", "Microsoft Corporation"},
+	}
+	for _, tt := range tests {
+		vendor, ok := oui.Lookup(tt.mac)
+		assert.True(t, ok, tt.mac)
+		assert.Equal(t, tt.vendor, vendor, tt.mac)
+	}
+
+	// Blocks divided into MA-M and MA-S assignments never resolve to the
+	// registration authority
+	for _, mac := range []string{"00:50:C2:00:00:01", "70:B3:D5:00:00:01", "00:1B:C5:00:00:01"} {
+		vendor, _ := oui.Lookup(mac)
+		assert.NotEqual(t, "IEEE Registration Authority", vendor, mac)
+	}
+}
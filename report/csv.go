@@ -0,0 +1,141 @@
+package report
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"hostscanner/scanner"
+)
+
+// ErrUnknownColumn is returned for a column name not in Columns.
+var ErrUnknownColumn = errors.New("unknown column")
+
+// Columns lists the columns a CSV export can contain, in their default
+// order. Latency is written in milliseconds.
+var Columns = []string{
+	"status", "ip", "hostname", "mac", "vendor", "latency",
+	"method", "evidence", "port", "error",
+}
+
+// DefaultColumns are the columns shown in the terminal UI's table.
+var DefaultColumns = slices.Clip(Columns[:6])
+
+// columnValues extracts the value of each column from a host.
+var columnValues = map[string]func(scanner.Host) string{
+	"status": func(h scanner.Host) string {
+		if h.IsAlive {
+			return "online"
+		}
+		return "offline"
+	},
+	"ip":       func(h scanner.Host) string { return h.IP.String() },
+	"hostname": func(h scanner.Host) string { return h.Hostname },
+	"mac":      func(h scanner.Host) string { return h.MAC },
+	"vendor":   func(h scanner.Host) string { return h.Vendor },
+	"latency": func(h scanner.Host) string {
+		if !h.IsAlive {
+			return ""
+		}
+		return strconv.FormatFloat(milliseconds(h.Latency), 'f', -1, 64)
+	},
+	"method":   func(h scanner.Host) string { return h.Method },
+	"evidence": func(h scanner.Host) string { return h.Evidence },
+	"port": func(h scanner.Host) string {
+		if h.Port == 0 {
+			return ""
+		}
+		return strconv.Itoa(h.Port)
+	},
+	"error": func(h scanner.Host) string {
+		if h.Error == nil {
+			return ""
+		}
+		return h.Error.Error()
+	},
+}
+
+// CSVOptions controls the output of WriteCSV.
+type CSVOptions struct {
+	// Columns selects the columns and their order. Defaults to
+	// DefaultColumns.
+	Columns []string
+	// Comma is the field delimiter. Defaults to ','; use '\t' for TSV.
+	Comma rune
+	// IncludeOffline also writes hosts that did not respond.
+	IncludeOffline bool
+}
+
+// ParseColumns parses a comma-separated list of column names.
+func ParseColumns(s string) ([]string, error) {
+	var columns []string
+	for _, name := range strings.Split(s, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		if _, ok := columnValues[name]; !ok {
+			return nil, fmt.Errorf("%w: %q", ErrUnknownColumn, name)
+		}
+		columns = append(columns, name)
+	}
+
+	return columns, nil
+}
+
+// WriteCSV writes the hosts of result as CSV with a header row. Fields are
+// quoted as needed, and fields a spreadsheet would take for a formula, such
+// as a hostname from reverse DNS starting with "=", are prefixed with a
+// quote so they are shown as text.
+func WriteCSV(w io.Writer, result *scanner.ScanResult, opts CSVOptions) error {
+	columns := opts.Columns
+	if len(columns) == 0 {
+		columns = DefaultColumns
+	}
+	values := make([]func(scanner.Host) string, len(columns))
+	for i, name := range columns {
+		value, ok := columnValues[name]
+		if !ok {
+			return fmt.Errorf("%w: %q", ErrUnknownColumn, name)
+		}
+		values[i] = value
+	}
+
+	cw := csv.NewWriter(w)
+	if opts.Comma != 0 {
+		cw.Comma = opts.Comma
+	}
+
+	if err := cw.Write(columns); err != nil {
+		return err
+	}
+
+	record := make([]string, len(columns))
+	for _, host := range result.Hosts {
+		if !host.IsAlive && !opts.IncludeOffline {
+			continue
+		}
+		for i, value := range values {
+			record[i] = escapeFormula(value(host))
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// escapeFormula prefixes s with a quote if it starts with a character that
+// makes spreadsheets evaluate it as a formula.
+func escapeFormula(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
@@ -0,0 +1,127 @@
+// Package report writes and reads scan results in file formats understood
+// by other tools.
+package report
+
+import (
+	"encoding/json"
+	"io"
+	"math"
+	"time"
+
+	"hostscanner/scanner"
+)
+
+// SchemaVersion is the version of the JSON documents written by this
+// package. It is increased whenever a field changes meaning or is removed.
+const SchemaVersion = 1
+
+// JSONResult is the JSON representation of a scanner.ScanResult.
+type JSONResult struct {
+	SchemaVersion int        `json:"schema_version"`
+	NetworkRange  string     `json:"network_range"`
+	TotalHosts    int        `json:"total_hosts"`
+	AliveHosts    int        `json:"alive_hosts"`
+	StartTime     time.Time  `json:"start_time"`
+	ScanTimeMs    float64    `json:"scan_time_ms"`
+	Cancelled     bool       `json:"cancelled,omitempty"`
+	Hosts         []JSONHost `json:"hosts"`
+}
+
+// JSONHost is the JSON representation of a scanner.Host. It is also the
+// shape of every line written by an NDJSONWriter.
+type JSONHost struct {
+	IP        string  `json:"ip"`
+	Alive     bool    `json:"alive"`
+	Hostname  string  `json:"hostname,omitempty"`
+	MAC       string  `json:"mac,omitempty"`
+	Vendor    string  `json:"vendor,omitempty"`
+	LatencyMs float64 `json:"latency_ms"`
+	Method    string  `json:"method,omitempty"`
+	Evidence  string  `json:"evidence,omitempty"`
+	Port      int     `json:"port,omitempty"`
+	Error     string  `json:"error,omitempty"`
+
+	// Addresses lists every address of the host when there are several.
+	Addresses []string `json:"addresses,omitempty"`
+}
+
+// NewJSONResult converts result to its JSON representation.
+func NewJSONResult(result *scanner.ScanResult) JSONResult {
+	r := JSONResult{
+		SchemaVersion: SchemaVersion,
+		NetworkRange:  result.NetworkRange,
+		TotalHosts:    result.TotalHosts,
+		AliveHosts:    result.AliveHosts,
+		StartTime:     result.StartTime,
+		ScanTimeMs:    milliseconds(result.ScanTime),
+		Cancelled:     result.Cancelled,
+		Hosts:         make([]JSONHost, 0, len(result.Hosts)),
+	}
+	for _, host := range result.Hosts {
+		r.Hosts = append(r.Hosts, NewJSONHost(host))
+	}
+
+	return r
+}
+
+// NewJSONHost converts host to its JSON representation.
+func NewJSONHost(host scanner.Host) JSONHost {
+	h := JSONHost{
+		IP:        host.IP.String(),
+		Alive:     host.IsAlive,
+		Hostname:  host.Hostname,
+		MAC:       host.MAC,
+		Vendor:    host.Vendor,
+		LatencyMs: milliseconds(host.Latency),
+		Method:    host.Method,
+		Evidence:  host.Evidence,
+		Port:      host.Port,
+	}
+	if host.Error != nil {
+		h.Error = host.Error.Error()
+	}
+	for _, ip := range host.Addresses {
+		h.Addresses = append(h.Addresses, ip.String())
+	}
+
+	return h
+}
+
+// WriteJSON writes result to w as an indented JSON document.
+func WriteJSON(w io.Writer, result *scanner.ScanResult) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(NewJSONResult(result))
+}
+
+// NDJSONWriter writes hosts as newline-delimited JSON, one JSONHost per
+// line, so results can be consumed while a scan is still running.
+type NDJSONWriter struct {
+	enc *json.Encoder
+}
+
+// NewNDJSONWriter returns a writer emitting lines to w.
+func NewNDJSONWriter(w io.Writer) *NDJSONWriter {
+	return &NDJSONWriter{enc: json.NewEncoder(w)}
+}
+
+// WriteHost writes host as a single line.
+func (w *NDJSONWriter) WriteHost(host scanner.Host) error {
+	return w.enc.Encode(NewJSONHost(host))
+}
+
+// WriteNDJSON writes all hosts of result as newline-delimited JSON.
+func WriteNDJSON(w io.Writer, result *scanner.ScanResult) error {
+	nw := NewNDJSONWriter(w)
+	for _, host := range result.Hosts {
+		if err := nw.WriteHost(host); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// milliseconds converts d to milliseconds with microsecond precision.
+func milliseconds(d time.Duration) float64 {
+	return math.Round(float64(d)/float64(time.Microsecond)) / 1000
+}
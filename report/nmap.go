@@ -0,0 +1,202 @@
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"hostscanner/scanner"
+)
+
+// NmapRun is the root element of nmap's XML output. Only the parts that
+// describe host discovery are modelled.
+type NmapRun struct {
+	XMLName          xml.Name     `xml:"nmaprun"`
+	Scanner          string       `xml:"scanner,attr"`
+	Args             string       `xml:"args,attr,omitempty"`
+	Start            int64        `xml:"start,attr"`
+	StartStr         string       `xml:"startstr,attr,omitempty"`
+	Version          string       `xml:"version,attr"`
+	ProfileName      string       `xml:"profile_name,attr,omitempty"`
+	XMLOutputVersion string       `xml:"xmloutputversion,attr"`
+	Verbose          NmapLevel    `xml:"verbose"`
+	Debugging        NmapLevel    `xml:"debugging"`
+	Hosts            []NmapHost   `xml:"host"`
+	RunStats         NmapRunStats `xml:"runstats"`
+}
+
+// NmapLevel is a <verbose> or <debugging> element.
+type NmapLevel struct {
+	Level int `xml:"level,attr"`
+}
+
+// NmapHost is a <host> element.
+type NmapHost struct {
+	Status    NmapStatus     `xml:"status"`
+	Addresses []NmapAddress  `xml:"address"`
+	Hostnames []NmapHostname `xml:"hostnames>hostname"`
+	Times     *NmapTimes     `xml:"times"`
+}
+
+// NmapStatus is a <status> element: "up" or "down" and why.
+type NmapStatus struct {
+	State     string `xml:"state,attr"`
+	Reason    string `xml:"reason,attr"`
+	ReasonTTL int    `xml:"reason_ttl,attr"`
+}
+
+// NmapAddress is an <address> element of type ipv4, ipv6 or mac.
+type NmapAddress struct {
+	Addr     string `xml:"addr,attr"`
+	AddrType string `xml:"addrtype,attr"`
+	Vendor   string `xml:"vendor,attr,omitempty"`
+}
+
+// NmapHostname is a <hostname> element.
+type NmapHostname struct {
+	Name string `xml:"name,attr"`
+	Type string `xml:"type,attr"`
+}
+
+// NmapTimes is a <times> element with round-trip times in microseconds.
+type NmapTimes struct {
+	SRTT   int64 `xml:"srtt,attr"`
+	RTTVar int64 `xml:"rttvar,attr"`
+	To     int64 `xml:"to,attr"`
+}
+
+// NmapRunStats is the <runstats> element.
+type NmapRunStats struct {
+	Finished NmapFinished `xml:"finished"`
+	Hosts    NmapHostStat `xml:"hosts"`
+}
+
+// NmapFinished is the <finished> element.
+type NmapFinished struct {
+	Time    int64  `xml:"time,attr"`
+	TimeStr string `xml:"timestr,attr,omitempty"`
+	Elapsed string `xml:"elapsed,attr"`
+	Summary string `xml:"summary,attr,omitempty"`
+	Exit    string `xml:"exit,attr"`
+}
+
+// NmapHostStat is the <hosts> element of <runstats>.
+type NmapHostStat struct {
+	Up    int `xml:"up,attr"`
+	Down  int `xml:"down,attr"`
+	Total int `xml:"total,attr"`
+}
+
+// nmapVersion is the nmap release whose output format NewNmapRun follows.
+// Importers such as Metasploit and Faraday reject files whose scanner is not
+// "nmap", so hostscanner names itself in profile_name and args instead.
+const nmapVersion = "7.94"
+
+// nmapTimeFormat is the layout of nmap's *str time attributes.
+const nmapTimeFormat = "Mon Jan 2 15:04:05 2006"
+
+// NewNmapRun converts result to nmap's XML structure.
+func NewNmapRun(result *scanner.ScanResult) NmapRun {
+	finished := result.StartTime.Add(result.ScanTime)
+	run := NmapRun{
+		Scanner:          "nmap",
+		Args:             strings.TrimSpace("hostscanner scan " + result.NetworkRange),
+		Start:            result.StartTime.Unix(),
+		StartStr:         result.StartTime.Format(nmapTimeFormat),
+		Version:          nmapVersion,
+		ProfileName:      "hostscanner",
+		XMLOutputVersion: "1.05",
+		Hosts:            make([]NmapHost, 0, len(result.Hosts)),
+	}
+
+	for _, host := range result.Hosts {
+		run.Hosts = append(run.Hosts, newNmapHost(host))
+	}
+
+	up := result.AliveHosts
+	down := result.TotalHosts - up
+	run.RunStats = NmapRunStats{
+		Finished: NmapFinished{
+			Time:    finished.Unix(),
+			TimeStr: finished.Format(nmapTimeFormat),
+			Elapsed: strconv.FormatFloat(result.ScanTime.Seconds(), 'f', 2, 64),
+			Summary: fmt.Sprintf("hostscanner done at %s; %d IP addresses (%d hosts up) scanned in %.2f seconds",
+				finished.Format(nmapTimeFormat), result.TotalHosts, up, result.ScanTime.Seconds()),
+			Exit: "success",
+		},
+		Hosts: NmapHostStat{Up: up, Down: down, Total: result.TotalHosts},
+	}
+	if result.Cancelled {
+		run.RunStats.Finished.Exit = "error"
+	}
+
+	return run
+}
+
+func newNmapHost(host scanner.Host) NmapHost {
+	h := NmapHost{
+		Status: NmapStatus{State: "down", Reason: "no-response"},
+	}
+
+	addrType := "ipv4"
+	if host.IP.To4() == nil {
+		addrType = "ipv6"
+	}
+	h.Addresses = append(h.Addresses, NmapAddress{Addr: host.IP.String(), AddrType: addrType})
+	if host.MAC != "" {
+		vendor := host.Vendor
+		if vendor == "Unknown" {
+			vendor = ""
+		}
+		h.Addresses = append(h.Addresses, NmapAddress{Addr: host.MAC, AddrType: "mac", Vendor: vendor})
+	}
+
+	if host.Hostname != "" {
+		h.Hostnames = append(h.Hostnames, NmapHostname{Name: host.Hostname, Type: "PTR"})
+	}
+
+	if host.IsAlive {
+		h.Status = NmapStatus{State: "up", Reason: nmapReason(host)}
+		// With a single sample there is no variance; nmap never uses a
+		// probe timeout below 100ms.
+		h.Times = &NmapTimes{SRTT: host.Latency.Microseconds(), To: (100 * time.Millisecond).Microseconds()}
+	}
+
+	return h
+}
+
+// nmapReason maps the probe that found a host to nmap's reason names.
+func nmapReason(host scanner.Host) string {
+	switch host.Method {
+	case "icmp", "ping":
+		return "echo-reply"
+	case "arp":
+		return "arp-response"
+	case "tcp":
+		if strings.HasSuffix(host.Evidence, "reset") {
+			return "conn-refused"
+		}
+		return "syn-ack"
+	}
+	return "user-set"
+}
+
+// WriteNmapXML writes result in nmap's XML output format, so tools that
+// import nmap results can read it.
+func WriteNmapXML(w io.Writer, result *scanner.ScanResult) error {
+	if _, err := io.WriteString(w, xml.Header+"<!DOCTYPE nmaprun>\n"); err != nil {
+		return err
+	}
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(NewNmapRun(result)); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,295 @@
+package report
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"hostscanner/network"
+	"hostscanner/scanner"
+)
+
+// Errors returned when reading results.
+var (
+	ErrUnsupportedSchema = errors.New("unsupported schema version")
+	ErrUnknownFormat     = errors.New("unknown results file format")
+)
+
+// ReadJSON parses a document written by WriteJSON.
+func ReadJSON(r io.Reader) (*scanner.ScanResult, error) {
+	var doc JSONResult
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON results: %w", err)
+	}
+	if doc.SchemaVersion < 1 || doc.SchemaVersion > SchemaVersion {
+		return nil, fmt.Errorf("%w: %d", ErrUnsupportedSchema, doc.SchemaVersion)
+	}
+
+	result := &scanner.ScanResult{
+		NetworkRange: doc.NetworkRange,
+		TotalHosts:   doc.TotalHosts,
+		AliveHosts:   doc.AliveHosts,
+		StartTime:    doc.StartTime,
+		ScanTime:     fromMilliseconds(doc.ScanTimeMs),
+		Cancelled:    doc.Cancelled,
+		Hosts:        make([]scanner.Host, 0, len(doc.Hosts)),
+	}
+	for _, h := range doc.Hosts {
+		host, err := h.Host()
+		if err != nil {
+			return nil, err
+		}
+		result.Hosts = append(result.Hosts, host)
+	}
+
+	// Documents written by hand or by other tools may omit the counters
+	countHosts(result)
+
+	return result, nil
+}
+
+// ReadNDJSON parses hosts written by WriteNDJSON or an NDJSONWriter, one
+// JSONHost per line. The lines carry no details of the scan, so only the
+// hosts and their counts are filled in.
+func ReadNDJSON(r io.Reader) (*scanner.ScanResult, error) {
+	result := &scanner.ScanResult{}
+
+	dec := json.NewDecoder(r)
+	for n := 1; ; n++ {
+		var h JSONHost
+		if err := dec.Decode(&h); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to parse NDJSON results, host %d: %w", n, err)
+		}
+
+		host, err := h.Host()
+		if err != nil {
+			return nil, err
+		}
+		result.Hosts = append(result.Hosts, host)
+	}
+	countHosts(result)
+
+	return result, nil
+}
+
+// countHosts raises the counters of result to at least the number of its
+// hosts and of those alive.
+func countHosts(result *scanner.ScanResult) {
+	alive := 0
+	for _, host := range result.Hosts {
+		if host.IsAlive {
+			alive++
+		}
+	}
+	result.TotalHosts = max(result.TotalHosts, len(result.Hosts))
+	result.AliveHosts = max(result.AliveHosts, alive)
+}
+
+// isHostLine reports whether the JSON object b is an NDJSON host line
+// rather than a results document.
+func isHostLine(b []byte) bool {
+	var probe struct {
+		SchemaVersion *int            `json:"schema_version"`
+		Hosts         json.RawMessage `json:"hosts"`
+		IP            *string         `json:"ip"`
+	}
+	if err := json.Unmarshal(b, &probe); err != nil {
+		return false
+	}
+
+	return probe.SchemaVersion == nil && probe.Hosts == nil && probe.IP != nil
+}
+
+// Host converts h back to a scanner.Host.
+func (h JSONHost) Host() (scanner.Host, error) {
+	ip := net.ParseIP(h.IP)
+	if ip == nil {
+		return scanner.Host{}, fmt.Errorf("invalid IP address in results: %q", h.IP)
+	}
+
+	host := scanner.Host{
+		IP:       ip,
+		Hostname: h.Hostname,
+		MAC:      h.MAC,
+		Vendor:   h.Vendor,
+		Latency:  fromMilliseconds(h.LatencyMs),
+		IsAlive:  h.Alive,
+		Method:   h.Method,
+		Evidence: h.Evidence,
+		Port:     h.Port,
+	}
+	if h.Error != "" {
+		host.Error = errors.New(h.Error)
+	}
+	for _, addr := range h.Addresses {
+		ip := net.ParseIP(addr)
+		if ip == nil {
+			return scanner.Host{}, fmt.Errorf("invalid IP address in results: %q", addr)
+		}
+		host.Addresses = append(host.Addresses, ip)
+	}
+
+	return host, nil
+}
+
+// ReadNmapXML parses nmap XML output, such as written by `nmap -oX` or
+// WriteNmapXML. Hosts are marked with the method "nmap" and the reason
+// nmap gave for their state. NetworkRange holds the address targets of the
+// command line, or is empty if it named none.
+func ReadNmapXML(r io.Reader) (*scanner.ScanResult, error) {
+	var run NmapRun
+	if err := xml.NewDecoder(r).Decode(&run); err != nil {
+		return nil, fmt.Errorf("failed to parse nmap XML: %w", err)
+	}
+
+	elapsed, _ := strconv.ParseFloat(run.RunStats.Finished.Elapsed, 64)
+	result := &scanner.ScanResult{
+		TotalHosts: run.RunStats.Hosts.Total,
+		StartTime:  time.Unix(run.Start, 0),
+		ScanTime:   time.Duration(elapsed * float64(time.Second)),
+		Hosts:      make([]scanner.Host, 0, len(run.Hosts)),
+	}
+
+	result.NetworkRange = nmapTargets(run.Args)
+
+	for _, h := range run.Hosts {
+		host := scanner.Host{
+			IsAlive:  h.Status.State == "up",
+			Method:   "nmap",
+			Evidence: h.Status.Reason,
+		}
+		for _, addr := range h.Addresses {
+			switch addr.AddrType {
+			case "ipv4", "ipv6":
+				host.IP = net.ParseIP(addr.Addr)
+			case "mac":
+				host.MAC = strings.ToUpper(addr.Addr)
+				host.Vendor = addr.Vendor
+			}
+		}
+		if host.IP == nil {
+			continue
+		}
+		if len(h.Hostnames) > 0 {
+			host.Hostname = h.Hostnames[0].Name
+		}
+		if h.Times != nil {
+			host.Latency = time.Duration(h.Times.SRTT) * time.Microsecond
+		}
+
+		result.Hosts = append(result.Hosts, host)
+		if host.IsAlive {
+			result.AliveHosts++
+		}
+	}
+
+	if result.TotalHosts < len(result.Hosts) {
+		result.TotalHosts = len(result.Hosts)
+	}
+
+	return result, nil
+}
+
+// nmapValueOptions are the nmap options whose value is a separate argument
+// that could be mistaken for a target, such as the output file of -oX or the
+// addresses of --exclude.
+var nmapValueOptions = map[string]bool{
+	"-oN": true, "-oX": true, "-oS": true, "-oG": true, "-oA": true,
+	"-iL": true, "-iR": true, "-e": true, "-S": true, "-D": true,
+	"-p": true, "-g": true, "--exclude": true, "--excludefile": true,
+	"--dns-servers": true, "--source-port": true, "--proxies": true,
+	"--script": true, "--script-args": true, "--datadir": true,
+	"--stylesheet": true, "--resume": true,
+}
+
+// nmapTargets returns the address targets of an nmap command line, joined
+// with commas. Options and their values are skipped, as are targets that
+// are not addresses, ranges or CIDR blocks, such as host names or the
+// subcommand of "hostscanner scan". It returns "" when there are none, so
+// the caller can describe the results by their hosts instead.
+func nmapTargets(args string) string {
+	fields := strings.Fields(args)
+	var targets []string
+	for i := 1; i < len(fields); i++ {
+		arg := fields[i]
+		if strings.HasPrefix(arg, "-") {
+			if nmapValueOptions[arg] {
+				i++
+			}
+			continue
+		}
+		if _, err := network.ParseIPRange(arg); err == nil {
+			targets = append(targets, arg)
+		}
+	}
+	return strings.Join(targets, ",")
+}
+
+// Read parses results in any format this package can read, telling JSON
+// and nmap XML apart by their first character, and a JSON document from
+// NDJSON by the fields of its first object.
+func Read(r io.Reader) (*scanner.ScanResult, error) {
+	br := bufio.NewReader(r)
+	for {
+		b, err := br.Peek(1)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrUnknownFormat, err)
+		}
+		if !bytes.ContainsAny(b, " \t\r\n") {
+			break
+		}
+		br.ReadByte()
+	}
+
+	b, _ := br.Peek(1)
+	switch b[0] {
+	case '{':
+		dec := json.NewDecoder(br)
+		var first json.RawMessage
+		if err := dec.Decode(&first); err != nil {
+			return nil, fmt.Errorf("failed to parse JSON results: %w", err)
+		}
+
+		rest := io.MultiReader(bytes.NewReader(first), dec.Buffered(), br)
+		if isHostLine(first) {
+			return ReadNDJSON(rest)
+		}
+		return ReadJSON(rest)
+	case '<':
+		return ReadNmapXML(br)
+	default:
+		return nil, ErrUnknownFormat
+	}
+}
+
+// ReadFile loads results saved as hostscanner JSON or NDJSON, or as nmap
+// XML.
+func ReadFile(path string) (*scanner.ScanResult, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	result, err := Read(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return result, nil
+}
+
+// fromMilliseconds converts milliseconds to a duration.
+func fromMilliseconds(ms float64) time.Duration {
+	return time.Duration(ms * float64(time.Millisecond))
+}
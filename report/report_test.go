@@ -0,0 +1,274 @@
+package report_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"hostscanner/report"
+	"hostscanner/scanner"
+)
+
+// sampleResult returns a small scan with one online and one offline host.
+func sampleResult() *scanner.ScanResult {
+	return &scanner.ScanResult{
+		NetworkRange: "192.168.1.0/30",
+		TotalHosts:   4,
+		AliveHosts:   1,
+		ScanTime:     1500 * time.Millisecond,
+		Hosts: []scanner.Host{
+			{
+				IP:       net.ParseIP("192.168.1.1"),
+				Hostname: "router.lan",
+				MAC:      "00:50:56:C0:00:01",
+				Vendor:   "VMware",
+				Latency:  1234 * time.Microsecond,
+				IsAlive:  true,
+				Method:   "icmp",
+				Evidence: "icmp echo reply",
+			},
+			{
+				IP:     net.ParseIP("192.168.1.2"),
+				Method: "icmp",
+				Error:  errors.New("context deadline exceeded"),
+			},
+		},
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, report.WriteJSON(&buf, sampleResult()))
+
+	var doc map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &doc))
+	assert.Equal(t, float64(report.SchemaVersion), doc["schema_version"])
+	assert.Equal(t, 1500.0, doc["scan_time_ms"])
+
+	hosts := doc["hosts"].([]any)
+	require.Len(t, hosts, 2)
+	online := hosts[0].(map[string]any)
+	assert.Equal(t, "192.168.1.1", online["ip"])
+	assert.Equal(t, 1.234, online["latency_ms"])
+	assert.NotContains(t, online, "error")
+	offline := hosts[1].(map[string]any)
+	assert.Equal(t, "context deadline exceeded", offline["error"])
+}
+
+func TestNDJSONWriter(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, report.WriteNDJSON(&buf, sampleResult()))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+	for _, line := range lines {
+		var host report.JSONHost
+		require.NoError(t, json.Unmarshal([]byte(line), &host))
+		assert.NotEmpty(t, host.IP)
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	result := sampleResult()
+	result.Hosts[0].Hostname = `router "main", lan`
+
+	var buf bytes.Buffer
+	require.NoError(t, report.WriteCSV(&buf, result, report.CSVOptions{}))
+	assert.Equal(t, "status,ip,hostname,mac,vendor,latency\n"+
+		`online,192.168.1.1,"router ""main"", lan",00:50:56:C0:00:01,VMware,1.234`+"\n",
+		buf.String())
+}
+
+func TestWriteCSV_Formulas(t *testing.T) {
+	result := sampleResult()
+	result.Hosts[0].Hostname = `=HYPERLINK("http://example.com")`
+	result.Hosts[0].Vendor = "@SUM(A1)"
+
+	var buf bytes.Buffer
+	require.NoError(t, report.WriteCSV(&buf, result, report.CSVOptions{}))
+	assert.Equal(t, "status,ip,hostname,mac,vendor,latency\n"+
+		`online,192.168.1.1,"'=HYPERLINK(""http://example.com"")",00:50:56:C0:00:01,'@SUM(A1),1.234`+"\n",
+		buf.String())
+}
+
+func TestDefaultColumns_NotAliased(t *testing.T) {
+	columns := append(report.DefaultColumns, "error")
+	assert.Equal(t, "error", columns[6])
+	assert.Equal(t, "method", report.Columns[6])
+}
+
+func TestWriteCSV_ColumnsAndOffline(t *testing.T) {
+	columns, err := report.ParseColumns("ip, Error,status")
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, report.WriteCSV(&buf, sampleResult(), report.CSVOptions{
+		Columns:        columns,
+		Comma:          '\t',
+		IncludeOffline: true,
+	}))
+	assert.Equal(t, "ip\terror\tstatus\n"+
+		"192.168.1.1\t\tonline\n"+
+		"192.168.1.2\tcontext deadline exceeded\toffline\n",
+		buf.String())
+
+	_, err = report.ParseColumns("ip,owner")
+	assert.ErrorIs(t, err, report.ErrUnknownColumn)
+}
+
+func TestWriteNmapXML(t *testing.T) {
+	result := sampleResult()
+	result.StartTime = time.Unix(1700000000, 0)
+
+	var buf bytes.Buffer
+	require.NoError(t, report.WriteNmapXML(&buf, result))
+	out := buf.String()
+
+	assert.True(t, strings.HasPrefix(out, "<?xml"))
+	assert.Contains(t, out, `<nmaprun scanner="nmap" args="hostscanner scan 192.168.1.0/30" start="1700000000"`)
+	assert.Contains(t, out, `version="7.94" profile_name="hostscanner" xmloutputversion="1.05">
+  <verbose level="0"></verbose>
+  <debugging level="0"></debugging>
+  <host>`)
+	assert.Contains(t, out, `<status state="up" reason="echo-reply" reason_ttl="0"></status>`)
+	assert.Contains(t, out, `<address addr="192.168.1.1" addrtype="ipv4"></address>`)
+	assert.Contains(t, out, `<address addr="00:50:56:C0:00:01" addrtype="mac" vendor="VMware"></address>`)
+	assert.Contains(t, out, `<hostname name="router.lan" type="PTR"></hostname>`)
+	assert.Contains(t, out, `<times srtt="1234" rttvar="0" to="100000"></times>`)
+	assert.Contains(t, out, `<status state="down" reason="no-response" reason_ttl="0"></status>`)
+	assert.Contains(t, out, `<hosts up="1" down="3" total="4"></hosts>`)
+}
+
+func TestReadJSON_RoundTrip(t *testing.T) {
+	original := sampleResult()
+	original.StartTime = time.Unix(1700000000, 0).UTC()
+
+	var buf bytes.Buffer
+	require.NoError(t, report.WriteJSON(&buf, original))
+	loaded, err := report.Read(&buf)
+	require.NoError(t, err)
+
+	assert.Equal(t, original.NetworkRange, loaded.NetworkRange)
+	assert.Equal(t, original.TotalHosts, loaded.TotalHosts)
+	assert.Equal(t, original.AliveHosts, loaded.AliveHosts)
+	assert.Equal(t, original.ScanTime, loaded.ScanTime)
+	assert.True(t, original.StartTime.Equal(loaded.StartTime))
+	require.Len(t, loaded.Hosts, 2)
+	assert.True(t, loaded.Hosts[0].IP.Equal(original.Hosts[0].IP))
+	assert.Equal(t, original.Hosts[0].Latency, loaded.Hosts[0].Latency)
+	assert.Equal(t, original.Hosts[0].MAC, loaded.Hosts[0].MAC)
+	assert.EqualError(t, loaded.Hosts[1].Error, "context deadline exceeded")
+}
+
+func TestReadJSON_FutureSchema(t *testing.T) {
+	_, err := report.ReadJSON(strings.NewReader(`{"schema_version": 99, "hosts": []}`))
+	assert.ErrorIs(t, err, report.ErrUnsupportedSchema)
+}
+
+func TestReadJSON_MissingCounters(t *testing.T) {
+	result, err := report.Read(strings.NewReader(`{"schema_version": 1, "hosts": [
+		{"ip": "10.0.0.1", "alive": true},
+		{"ip": "10.0.0.2", "alive": true},
+		{"ip": "10.0.0.3", "alive": false}
+	]}`))
+	require.NoError(t, err)
+	assert.Equal(t, 3, result.TotalHosts)
+	assert.Equal(t, 2, result.AliveHosts)
+}
+
+func TestRead_NDJSON(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, report.WriteNDJSON(&buf, sampleResult()))
+
+	result, err := report.Read(&buf)
+	require.NoError(t, err)
+	require.Len(t, result.Hosts, 2)
+	assert.Equal(t, "192.168.1.1", result.Hosts[0].IP.String())
+	assert.Equal(t, "router.lan", result.Hosts[0].Hostname)
+	assert.Equal(t, 2, result.TotalHosts)
+	assert.Equal(t, 1, result.AliveHosts)
+
+	_, err = report.Read(strings.NewReader("{\"ip\": \"10.0.0.1\"}\n{\"ip\": 5}\n"))
+	assert.ErrorContains(t, err, "host 2")
+}
+
+func TestReadNmapXML(t *testing.T) {
+	const nmapOutput = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE nmaprun>
+<nmaprun scanner="nmap" args="nmap -sn -oX - 192.168.1.0/24" start="1700000000" version="7.94" xmloutputversion="1.05">
+<host><status state="up" reason="arp-response" reason_ttl="0"/>
+<address addr="192.168.1.1" addrtype="ipv4"/>
+<address addr="b8:27:eb:00:00:01" addrtype="mac" vendor="Raspberry Pi Foundation"/>
+<hostnames><hostname name="pi.lan" type="PTR"/></hostnames>
+<times srtt="850" rttvar="5000" to="100000"/>
+</host>
+<host><status state="down" reason="no-response" reason_ttl="0"/>
+<address addr="192.168.1.2" addrtype="ipv4"/>
+</host>
+<runstats><finished time="1700000003" elapsed="2.50" exit="success"/><hosts up="1" down="255" total="256"/></runstats>
+</nmaprun>`
+
+	result, err := report.Read(strings.NewReader(nmapOutput))
+	require.NoError(t, err)
+
+	assert.Equal(t, "192.168.1.0/24", result.NetworkRange)
+	assert.Equal(t, 256, result.TotalHosts)
+	assert.Equal(t, 1, result.AliveHosts)
+	assert.Equal(t, 2500*time.Millisecond, result.ScanTime)
+	require.Len(t, result.Hosts, 2)
+
+	pi := result.Hosts[0]
+	assert.True(t, pi.IsAlive)
+	assert.Equal(t, "192.168.1.1", pi.IP.String())
+	assert.Equal(t, "B8:27:EB:00:00:01", pi.MAC)
+	assert.Equal(t, "Raspberry Pi Foundation", pi.Vendor)
+	assert.Equal(t, "pi.lan", pi.Hostname)
+	assert.Equal(t, 850*time.Microsecond, pi.Latency)
+	assert.Equal(t, "arp-response", pi.Evidence)
+	assert.False(t, result.Hosts[1].IsAlive)
+}
+
+func TestReadNmapXML_Targets(t *testing.T) {
+	for args, want := range map[string]string{
+		"nmap -sn 192.168.1.0/24 -oX out.xml":                    "192.168.1.0/24",
+		"nmap -sn --exclude 10.0.0.5 -oX - 10.0.0.0/28 10.0.1.1": "10.0.0.0/28,10.0.1.1",
+		"hostscanner scan 192.168.1.1-192.168.1.20":              "192.168.1.1-192.168.1.20",
+		"nmap -sn -oX scan.xml scanme.nmap.org":                  "",
+	} {
+		result, err := report.Read(strings.NewReader(`<nmaprun scanner="nmap" args="` + args + `"></nmaprun>`))
+		require.NoError(t, err)
+		assert.Equal(t, want, result.NetworkRange, args)
+	}
+}
+
+func TestRead_UnknownFormat(t *testing.T) {
+	_, err := report.Read(strings.NewReader("ip,mac\n"))
+	assert.ErrorIs(t, err, report.ErrUnknownFormat)
+}
+
+func TestReadJSON_Addresses(t *testing.T) {
+	original := &scanner.ScanResult{
+		NetworkRange: "ndp:eth0",
+		Hosts: []scanner.Host{{
+			IP:        net.ParseIP("2001:db8::10"),
+			Addresses: []net.IP{net.ParseIP("2001:db8::10"), net.ParseIP("fe80::1")},
+			IsAlive:   true,
+			Method:    "ndp",
+		}},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, report.WriteJSON(&buf, original))
+	assert.Contains(t, buf.String(), `"addresses": [`)
+
+	loaded, err := report.Read(&buf)
+	require.NoError(t, err)
+	require.Len(t, loaded.Hosts, 1)
+	assert.Equal(t, original.Hosts[0].Addresses, loaded.Hosts[0].Addresses)
+}
@@ -0,0 +1,406 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"hostscanner/config"
+	"hostscanner/inventory"
+	"hostscanner/network"
+	"hostscanner/report"
+	"hostscanner/scanner"
+)
+
+// discoveryOptions returns the probe options shared by the terminal UI and
+// the scan command for the enabled methods: ICMP, TCP on the configured
+// ports, and ARP when the whole range lies on a directly connected subnet.
+func discoveryOptions(ipr *network.IPRange, settings config.Settings) []scanner.Option {
+	opts := []scanner.Option{
+		scanner.WithICMP(settings.Uses(config.MethodICMP)),
+		scanner.WithRetries(settings.Retries),
+		scanner.WithReverseDNS(settings.DNS),
+	}
+	if settings.Uses(config.MethodTCP) {
+		opts = append(opts, scanner.WithTCPPorts(tcpPortsOf(settings)...))
+	}
+	if settings.Uses(config.MethodARP) {
+		if lan, err := network.LocalNetworkFor(ipr); err == nil {
+			opts = append(opts, scanner.WithARP(&lan.Interface, lan.Network))
+		}
+	}
+
+	return opts
+}
+
+// tcpPortsOf returns the ports TCP probes try with settings.
+func tcpPortsOf(settings config.Settings) []int {
+	if len(settings.TCPPorts) == 0 {
+		return scanner.DefaultTCPPorts
+	}
+
+	return settings.TCPPorts
+}
+
+// loadConfig reads the user's config file.
+func loadConfig() (*config.Config, error) {
+	path, err := config.DefaultPath()
+	if err != nil {
+		return nil, err
+	}
+
+	return config.Load(path)
+}
+
+// importedTargets returns the addresses of the hosts in a previously saved
+// result that are not in exclude.
+func importedTargets(result *scanner.ScanResult, exclude []string) (*network.RangeSet, error) {
+	if len(result.Hosts) == 0 {
+		return nil, errors.New("no hosts to rescan")
+	}
+
+	var targets network.RangeSet
+	for _, host := range result.Hosts {
+		targets.AddIP(host.IP)
+	}
+
+	return excludeTargets(&targets, exclude)
+}
+
+// expandTargets returns the addresses in the ranges of targets that are not
+// in any range of exclude.
+func expandTargets(ranges, exclude []string) (*network.RangeSet, error) {
+	var targets network.RangeSet
+	for _, r := range ranges {
+		ipr, err := network.ParseIPRange(strings.TrimSpace(r))
+		if err != nil {
+			return nil, fmt.Errorf("invalid IP range: %w", err)
+		}
+		targets.Add(ipr)
+	}
+
+	return excludeTargets(&targets, exclude)
+}
+
+// excludeTargets removes the ranges of exclude from targets.
+func excludeTargets(targets *network.RangeSet, exclude []string) (*network.RangeSet, error) {
+	for _, r := range exclude {
+		ipr, err := network.ParseIPRange(strings.TrimSpace(r))
+		if err != nil {
+			return nil, fmt.Errorf("invalid excluded range: %w", err)
+		}
+		targets.Remove(ipr)
+	}
+	if targets.Len() == 0 {
+		return nil, errors.New("no addresses left to scan")
+	}
+
+	return targets, nil
+}
+
+// splitTargets splits a comma-separated list of ranges.
+func splitTargets(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool { return r == ',' })
+}
+
+// recordInventory adds the hosts found by a scan to the user's inventory.
+func recordInventory(result *scanner.ScanResult) error {
+	path, err := inventory.DefaultPath()
+	if err != nil {
+		return err
+	}
+
+	return inventory.Update(path, result)
+}
+
+// outputFormats lists the formats accepted by --format.
+var outputFormats = []string{"text", "json", "ndjson", "csv", "tsv", "xml"}
+
+// outputOptions selects how scan results are written.
+type outputOptions struct {
+	format  string
+	all     bool     // include offline hosts
+	columns []string // CSV and TSV columns; empty for the defaults
+}
+
+// runScan scans one or more ranges without the terminal UI and writes the
+// results to stdout. An interrupt stops the scan and prints the partial
+// results.
+func runScan(args []string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	settings := cfg.Settings
+
+	fs := flag.NewFlagSet("scan", flag.ContinueOnError)
+	fs.DurationVar(&settings.Timeout, "timeout", settings.Timeout, "time to wait for each host")
+	fs.IntVar(&settings.Workers, "workers", settings.Workers, "number of hosts probed concurrently")
+	fs.IntVar(&settings.Retries, "retries", settings.Retries, "extra attempts for hosts that do not answer")
+	fs.BoolVar(&settings.DNS, "dns", settings.DNS, "look up host names")
+	methods := fs.String("methods", strings.Join(settings.Methods, ","),
+		"probe methods, from: "+strings.Join(config.Methods, ", "))
+	tcpPorts := fs.String("tcp-ports", config.FormatPorts(tcpPortsOf(settings)), "comma-separated ports tried by TCP probes")
+	format := fs.String("format", "text", "output format: "+strings.Join(outputFormats, ", "))
+	all := fs.Bool("all", false, "include offline hosts")
+	columns := fs.String("columns", strings.Join(report.DefaultColumns, ","),
+		"columns for csv and tsv output, from: "+strings.Join(report.Columns, ", "))
+	noInventory := fs.Bool("no-inventory", false, "do not record the results in the inventory")
+	targetsFrom := fs.String("targets-from", "", "rescan the hosts listed in a JSON, NDJSON or nmap XML results file")
+	profileName := fs.String("profile", "", "use the targets and settings of a profile from the config file")
+	ndp := fs.String("ndp", "", "discover the IPv6 hosts on the link of an interface instead of scanning a range")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: hostscanner scan <range>[,<range>...] [flags]")
+		fmt.Fprintln(fs.Output(), "       hostscanner scan --profile <name> [<range>] [flags]")
+		fmt.Fprintln(fs.Output(), "       hostscanner scan --targets-from <file> [flags]")
+		fmt.Fprintln(fs.Output(), "       hostscanner scan --ndp <interface> [flags]")
+		fs.PrintDefaults()
+	}
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return errUsage
+	}
+	switch {
+	case len(positional) > 1,
+		*ndp != "" && (len(positional) != 0 || *targetsFrom != ""),
+		*targetsFrom != "" && len(positional) != 0,
+		*targetsFrom == "" && *ndp == "" && *profileName == "" && len(positional) == 0:
+		fs.Usage()
+		return errUsage
+	}
+
+	var profile config.Profile
+	if *profileName != "" {
+		if profile, err = cfg.Profile(*profileName); err != nil {
+			return err
+		}
+
+		// Flags given on the command line override the profile
+		given := make(map[string]string)
+		fs.Visit(func(f *flag.Flag) { given[f.Name] = f.Value.String() })
+		settings = profile.Apply(cfg.Settings)
+		*methods = strings.Join(settings.Methods, ",")
+		*tcpPorts = config.FormatPorts(tcpPortsOf(settings))
+		if profile.Format != "" {
+			*format = profile.Format
+		}
+		for name, value := range given {
+			fs.Set(name, value)
+		}
+	}
+	settings.Methods = strings.Split(*methods, ",")
+	if settings.TCPPorts, err = config.ParsePorts(*tcpPorts); err != nil {
+		return err
+	}
+	if err := settings.Validate(); err != nil {
+		return err
+	}
+	if !slices.Contains(outputFormats, *format) {
+		return fmt.Errorf("unknown output format %q", *format)
+	}
+	out := outputOptions{format: *format, all: *all}
+	if out.columns, err = report.ParseColumns(*columns); err != nil {
+		return err
+	}
+
+	var (
+		targets *network.RangeSet
+		iface   *net.Interface
+		ipRange string
+	)
+	switch {
+	case *ndp != "":
+		if iface, err = net.InterfaceByName(*ndp); err != nil {
+			return fmt.Errorf("%s: %w", *ndp, err)
+		}
+		ipRange = "ndp:" + iface.Name
+	case *targetsFrom != "":
+		previous, err := report.ReadFile(*targetsFrom)
+		if err != nil {
+			return err
+		}
+		if targets, err = importedTargets(previous, profile.Exclude); err != nil {
+			return fmt.Errorf("%s: %w", *targetsFrom, err)
+		}
+		ipRange = previous.NetworkRange
+		if ipRange == "" {
+			ipRange = *targetsFrom
+		}
+	default:
+		// A range on the command line replaces the profile's targets
+		ranges := profile.Targets
+		if len(positional) == 1 {
+			ranges = splitTargets(positional[0])
+		}
+		ipRange = strings.Join(ranges, ",")
+		if targets, err = expandTargets(ranges, profile.Exclude); err != nil {
+			if errors.Is(err, network.ErrRangeTooLarge) {
+				return fmt.Errorf("%w; use --ndp <interface> to discover IPv6 hosts on a link", err)
+			}
+			return err
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	// NDJSON is written as hosts complete rather than at the end
+	var (
+		opts      []scanner.Option
+		streamErr error
+	)
+	if *format == "ndjson" {
+		nw := report.NewNDJSONWriter(os.Stdout)
+		opts = append(opts, scanner.WithHostFunc(func(host scanner.Host) {
+			if (host.IsAlive || *all) && streamErr == nil {
+				streamErr = nw.WriteHost(host)
+			}
+		}))
+	}
+
+	var result *scanner.ScanResult
+	if iface != nil {
+		opts = append(opts, scanner.WithRetries(settings.Retries), scanner.WithReverseDNS(settings.DNS))
+		if result, err = scanner.DiscoverIPv6(ctx, iface, settings.Timeout, opts...); err != nil {
+			return err
+		}
+	} else {
+		// Offline hosts are only kept when they are printed
+		opts = append(discoveryOptions(targets.Span(), settings), opts...)
+		opts = append(opts, scanner.WithOfflineHosts(*all))
+		result = scanner.ScanAddrs(ctx, targets.Addrs(), targets.Len(), settings.Timeout, settings.Workers, opts...)
+	}
+	result.NetworkRange = ipRange
+
+	if !*noInventory {
+		if err := recordInventory(result); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to update inventory: %v\n", err)
+		}
+	}
+
+	if *format == "ndjson" {
+		return streamErr
+	}
+	return writeResult(os.Stdout, result, out)
+}
+
+// writeResult writes result to w as selected by out. Hosts are written in
+// address order, except for NDJSON, which keeps the order they completed.
+func writeResult(w io.Writer, result *scanner.ScanResult, out outputOptions) error {
+	if out.format != "ndjson" {
+		result = sortedHosts(result)
+	}
+
+	switch out.format {
+	case "text":
+		return writeText(w, visibleHosts(result, out.all))
+	case "json":
+		return report.WriteJSON(w, visibleHosts(result, out.all))
+	case "ndjson":
+		return report.WriteNDJSON(w, visibleHosts(result, out.all))
+	case "csv", "tsv":
+		opts := report.CSVOptions{Columns: out.columns, IncludeOffline: out.all}
+		if out.format == "tsv" {
+			opts.Comma = '\t'
+		}
+		return report.WriteCSV(w, result, opts)
+	case "xml":
+		return report.WriteNmapXML(w, visibleHosts(result, out.all))
+	default:
+		return fmt.Errorf("unknown output format %q", out.format)
+	}
+}
+
+// sortedHosts returns result with its hosts in ascending address order.
+func sortedHosts(result *scanner.ScanResult) *scanner.ScanResult {
+	sorted := *result
+	sorted.Hosts = slices.Clone(result.Hosts)
+	slices.SortFunc(sorted.Hosts, func(a, b scanner.Host) int {
+		return bytes.Compare(a.IP.To16(), b.IP.To16())
+	})
+
+	return &sorted
+}
+
+// visibleHosts returns result with offline hosts removed unless all is
+// set. The counters still describe the whole scan.
+func visibleHosts(result *scanner.ScanResult, all bool) *scanner.ScanResult {
+	if all {
+		return result
+	}
+
+	visible := *result
+	visible.Hosts = make([]scanner.Host, 0, result.AliveHosts)
+	for _, host := range result.Hosts {
+		if host.IsAlive {
+			visible.Hosts = append(visible.Hosts, host)
+		}
+	}
+
+	return &visible
+}
+
+// writeText writes result as an aligned table followed by a summary line.
+func writeText(w io.Writer, result *scanner.ScanResult) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "STATUS\tIP\tHOSTNAME\tMAC\tVENDOR\tLATENCY")
+	for _, host := range result.Hosts {
+		status, latency := "offline", "-"
+		if host.IsAlive {
+			status = "online"
+			latency = host.Latency.Round(100 * time.Microsecond).String()
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			status, host.IP, orDash(host.Hostname), orDash(host.MAC), orDash(host.Vendor), latency)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	summary := fmt.Sprintf("\n%d of %d hosts alive in %s (%v)",
+		result.AliveHosts, result.TotalHosts, result.NetworkRange, result.ScanTime.Round(time.Millisecond))
+	if result.Cancelled {
+		summary += ", scan cancelled"
+	}
+	_, err := fmt.Fprintln(w, summary)
+	return err
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// parseArgs parses fs from args, allowing flags both before and after
+// positional arguments, and returns the positional arguments.
+func parseArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		if strings.HasPrefix(args[0], "-") {
+			// Everything after a "--" terminator is positional.
+			return append(positional, args...), nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
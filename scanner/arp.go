@@ -0,0 +1,73 @@
+package scanner
+
+import (
+	"encoding/binary"
+	"errors"
+	"net"
+)
+
+// ErrNotOnLink is returned by an ARPProber for addresses outside the
+// subnet of its interface, which cannot be resolved with ARP.
+var ErrNotOnLink = errors.New("address not on the local link")
+
+// ARP and Ethernet constants for IPv4 over Ethernet.
+const (
+	etherTypeIPv4 = 0x0800
+	etherTypeARP  = 0x0806
+	etherHdrLen   = 14
+	arpPacketLen  = 28
+	arpOpRequest  = 1
+	arpOpReply    = 2
+)
+
+// arpPacket is an ARP message for IPv4 over Ethernet.
+type arpPacket struct {
+	op        uint16
+	senderMAC net.HardwareAddr
+	senderIP  net.IP
+	targetMAC net.HardwareAddr
+	targetIP  net.IP
+}
+
+// marshalARPFrame returns an Ethernet frame carrying p, addressed to dst.
+func marshalARPFrame(dst net.HardwareAddr, p arpPacket) []byte {
+	b := make([]byte, etherHdrLen+arpPacketLen)
+
+	copy(b[0:6], dst)
+	copy(b[6:12], p.senderMAC)
+	binary.BigEndian.PutUint16(b[12:14], etherTypeARP)
+
+	a := b[etherHdrLen:]
+	binary.BigEndian.PutUint16(a[0:2], 1) // Ethernet
+	binary.BigEndian.PutUint16(a[2:4], etherTypeIPv4)
+	a[4] = 6
+	a[5] = 4
+	binary.BigEndian.PutUint16(a[6:8], p.op)
+	copy(a[8:14], p.senderMAC)
+	copy(a[14:18], p.senderIP.To4())
+	copy(a[18:24], p.targetMAC)
+	copy(a[24:28], p.targetIP.To4())
+
+	return b
+}
+
+// parseARPFrame decodes an Ethernet frame carrying an IPv4 ARP message.
+// It reports false for any other frame.
+func parseARPFrame(b []byte) (arpPacket, bool) {
+	if len(b) < etherHdrLen+arpPacketLen || binary.BigEndian.Uint16(b[12:14]) != etherTypeARP {
+		return arpPacket{}, false
+	}
+
+	a := b[etherHdrLen:]
+	if binary.BigEndian.Uint16(a[2:4]) != etherTypeIPv4 || a[4] != 6 || a[5] != 4 {
+		return arpPacket{}, false
+	}
+
+	return arpPacket{
+		op:        binary.BigEndian.Uint16(a[6:8]),
+		senderMAC: net.HardwareAddr(append([]byte(nil), a[8:14]...)),
+		senderIP:  net.IP(append([]byte(nil), a[14:18]...)),
+		targetMAC: net.HardwareAddr(append([]byte(nil), a[18:24]...)),
+		targetIP:  net.IP(append([]byte(nil), a[24:28]...)),
+	}, true
+}
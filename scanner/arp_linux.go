@@ -0,0 +1,172 @@
+package scanner
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// arpRetryInterval is how often an unanswered ARP request is repeated.
+const arpRetryInterval = 250 * time.Millisecond
+
+// ARPProber resolves hosts on a directly connected IPv4 subnet by sending
+// ARP requests from an AF_PACKET socket bound to one interface. It reports
+// whether the host is alive and its MAC address in a single step, and also
+// finds hosts that drop ICMP. Opening the socket requires root or
+// CAP_NET_RAW.
+type ARPProber struct {
+	iface  *net.Interface
+	local  *net.IPNet
+	socket *os.File
+
+	mu      sync.Mutex
+	pending map[netip.Addr]chan arpPacket
+}
+
+// NewARPProber opens an ARP socket on iface. local holds the interface's
+// IPv4 address and subnet mask; only addresses inside it are probed.
+// The returned prober must be closed when no longer needed.
+func NewARPProber(iface *net.Interface, local *net.IPNet) (*ARPProber, error) {
+	if local.IP.To4() == nil {
+		return nil, fmt.Errorf("%w: %v is not IPv4", ErrNotOnLink, local.IP)
+	}
+	if len(iface.HardwareAddr) != 6 {
+		return nil, fmt.Errorf("interface %s has no Ethernet address", iface.Name)
+	}
+
+	proto := htons(etherTypeARP)
+	fd, err := unix.Socket(unix.AF_PACKET, unix.SOCK_RAW|unix.SOCK_CLOEXEC|unix.SOCK_NONBLOCK, int(proto))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open ARP socket: %w", err)
+	}
+	if err := unix.Bind(fd, &unix.SockaddrLinklayer{Protocol: proto, Ifindex: iface.Index}); err != nil {
+		unix.Close(fd)
+		return nil, fmt.Errorf("failed to bind ARP socket to %s: %w", iface.Name, err)
+	}
+
+	p := &ARPProber{
+		iface:   iface,
+		local:   &net.IPNet{IP: local.IP.To4(), Mask: local.Mask},
+		socket:  os.NewFile(uintptr(fd), "arp:"+iface.Name),
+		pending: make(map[netip.Addr]chan arpPacket),
+	}
+	go p.readReplies()
+
+	return p, nil
+}
+
+// Close closes the prober's socket.
+func (p *ARPProber) Close() error {
+	return p.socket.Close()
+}
+
+// Probe sends ARP requests for ip until a reply arrives or the context is
+// done. Addresses outside the prober's subnet fail with ErrNotOnLink.
+func (p *ARPProber) Probe(ctx context.Context, ip net.IP) (ProbeResult, error) {
+	res := ProbeResult{Method: "arp"}
+
+	ip4 := ip.To4()
+	if ip4 == nil || !p.local.Contains(ip4) {
+		return res, fmt.Errorf("%w: %v", ErrNotOnLink, ip)
+	}
+	if ip4.Equal(p.local.IP) {
+		// The kernel does not answer its own requests on the wire.
+		res.Alive = true
+		res.MAC = strings.ToUpper(p.iface.HardwareAddr.String())
+		res.Evidence = "local interface " + p.iface.Name
+		return res, nil
+	}
+
+	addr, _ := netip.AddrFromSlice(ip4)
+	reply := make(chan arpPacket, 1)
+	p.mu.Lock()
+	if _, busy := p.pending[addr]; busy {
+		p.mu.Unlock()
+		return res, fmt.Errorf("ARP request for %v already in flight", ip)
+	}
+	p.pending[addr] = reply
+	p.mu.Unlock()
+	defer func() {
+		p.mu.Lock()
+		delete(p.pending, addr)
+		p.mu.Unlock()
+	}()
+
+	frame := marshalARPFrame(layer2Broadcast, arpPacket{
+		op:        arpOpRequest,
+		senderMAC: p.iface.HardwareAddr,
+		senderIP:  p.local.IP,
+		targetMAC: make(net.HardwareAddr, 6),
+		targetIP:  ip4,
+	})
+
+	retry := time.NewTicker(arpRetryInterval)
+	defer retry.Stop()
+
+	start := time.Now()
+	for {
+		if _, err := p.socket.Write(frame); err != nil {
+			return res, fmt.Errorf("failed to send ARP request: %w", err)
+		}
+
+		select {
+		case pkt := <-reply:
+			res.Alive = true
+			res.Latency = time.Since(start)
+			res.MAC = strings.ToUpper(pkt.senderMAC.String())
+			res.Evidence = "arp reply from " + res.MAC
+			return res, nil
+		case <-retry.C:
+		case <-ctx.Done():
+			res.Latency = time.Since(start)
+			return res, ctx.Err()
+		}
+	}
+}
+
+// readReplies hands ARP replies to the probes waiting for them until the
+// socket is closed.
+func (p *ARPProber) readReplies() {
+	buf := make([]byte, 1500)
+	for {
+		n, err := p.socket.Read(buf)
+		if err != nil {
+			if errors.Is(err, os.ErrClosed) {
+				return
+			}
+			continue
+		}
+
+		pkt, ok := parseARPFrame(buf[:n])
+		if !ok || pkt.op != arpOpReply {
+			continue
+		}
+		addr, _ := netip.AddrFromSlice(pkt.senderIP)
+
+		p.mu.Lock()
+		if reply, ok := p.pending[addr]; ok {
+			select {
+			case reply <- pkt:
+			default:
+			}
+		}
+		p.mu.Unlock()
+	}
+}
+
+// layer2Broadcast is the Ethernet broadcast address.
+var layer2Broadcast = net.HardwareAddr{0xff, 0xff, 0xff, 0xff, 0xff, 0xff}
+
+// htons converts a short from host to network byte order.
+func htons(v uint16) uint16 {
+	return binary.NativeEndian.Uint16(binary.BigEndian.AppendUint16(nil, v))
+}
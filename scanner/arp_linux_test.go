@@ -0,0 +1,98 @@
+package scanner_test
+
+import (
+	"context"
+	"net"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"hostscanner/scanner"
+)
+
+// setupVeth creates a veth pair with one end in a fresh network namespace,
+// addressed 10.203.0.1/24 locally and 10.203.0.2/24 inside the namespace.
+// It returns the local interface and the MAC address of the peer.
+func setupVeth(t *testing.T) (*net.Interface, string) {
+	if os.Geteuid() != 0 {
+		t.Skip("Creating network namespaces requires root")
+	}
+
+	const ns, local, peer = "hostscanner-test", "hsveth0", "hsveth1"
+	run := func(args ...string) error {
+		out, err := exec.Command("ip", args...).CombinedOutput()
+		if err != nil {
+			t.Logf("ip %s: %v: %s", strings.Join(args, " "), err, out)
+		}
+		return err
+	}
+
+	exec.Command("ip", "netns", "del", ns).Run()
+	if run("netns", "add", ns) != nil {
+		t.Skip("Network namespaces not available")
+	}
+	t.Cleanup(func() {
+		exec.Command("ip", "link", "del", local).Run()
+		exec.Command("ip", "netns", "del", ns).Run()
+	})
+
+	steps := [][]string{
+		{"link", "add", local, "type", "veth", "peer", "name", peer},
+		{"link", "set", peer, "netns", ns},
+		{"addr", "add", "10.203.0.1/24", "dev", local},
+		{"link", "set", local, "up"},
+		{"-n", ns, "addr", "add", "10.203.0.2/24", "dev", peer},
+		{"-n", ns, "link", "set", peer, "up"},
+	}
+	for _, step := range steps {
+		if run(step...) != nil {
+			t.Skip("Could not set up veth pair")
+		}
+	}
+
+	out, err := exec.Command("ip", "netns", "exec", ns, "cat", "/sys/class/net/"+peer+"/address").Output()
+	require.NoError(t, err)
+
+	iface, err := net.InterfaceByName(local)
+	require.NoError(t, err)
+
+	return iface, strings.ToUpper(strings.TrimSpace(string(out)))
+}
+
+func TestARPProber_Veth(t *testing.T) {
+	iface, peerMAC := setupVeth(t)
+
+	_, subnet, _ := net.ParseCIDR("10.203.0.0/24")
+	subnet.IP = net.ParseIP("10.203.0.1")
+	prober, err := scanner.NewARPProber(iface, subnet)
+	require.NoError(t, err)
+	defer prober.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	res, err := prober.Probe(ctx, net.ParseIP("10.203.0.2"))
+	assert.NoError(t, err)
+	assert.True(t, res.Alive)
+	assert.Equal(t, peerMAC, res.MAC)
+
+	// Nothing answers for an unused address on the link.
+	short, cancelShort := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancelShort()
+	res, err = prober.Probe(short, net.ParseIP("10.203.0.3"))
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.False(t, res.Alive)
+
+	_, err = prober.Probe(ctx, net.ParseIP("198.51.100.1"))
+	assert.ErrorIs(t, err, scanner.ErrNotOnLink)
+
+	// The scanner takes the MAC address straight from the ARP reply.
+	result := scanner.ScanNetwork([]net.IP{net.ParseIP("10.203.0.2")}, time.Second, 1,
+		scanner.WithProber(prober))
+	assert.True(t, result.Hosts[0].IsAlive)
+	assert.Equal(t, peerMAC, result.Hosts[0].MAC)
+}
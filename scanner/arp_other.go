@@ -0,0 +1,29 @@
+//go:build !linux
+
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"runtime"
+)
+
+// ARPProber resolves hosts on the local link with ARP requests.
+// It is only supported on Linux.
+type ARPProber struct{}
+
+// NewARPProber always fails on this operating system.
+func NewARPProber(iface *net.Interface, local *net.IPNet) (*ARPProber, error) {
+	return nil, fmt.Errorf("%w: %s", ErrUnsupportedOS, runtime.GOOS)
+}
+
+// Probe always fails on this operating system.
+func (p *ARPProber) Probe(ctx context.Context, ip net.IP) (ProbeResult, error) {
+	return ProbeResult{Method: "arp"}, fmt.Errorf("%w: %s", ErrUnsupportedOS, runtime.GOOS)
+}
+
+// Close does nothing on this operating system.
+func (p *ARPProber) Close() error {
+	return nil
+}
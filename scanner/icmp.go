@@ -0,0 +1,234 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+)
+
+// ErrICMPUnavailable is returned when no ICMP socket could be opened for
+// the address family of a probed host.
+var ErrICMPUnavailable = errors.New("icmp socket unavailable")
+
+// IANA protocol numbers used by icmp.ParseMessage.
+const (
+	protocolICMP     = 1
+	protocolIPv6ICMP = 58
+)
+
+// ICMPProber probes hosts with ICMP echo requests sent from one shared
+// socket per address family. Replies are matched to requests by source
+// address and sequence number, so a single prober can serve every worker
+// of a scan.
+//
+// On Linux an unprivileged datagram socket is used when the user's group
+// is allowed by net.ipv4.ping_group_range; otherwise a raw socket is
+// opened, which requires root or CAP_NET_RAW.
+type ICMPProber struct {
+	id  int
+	seq atomic.Uint32
+
+	v4 *icmpConn
+	v6 *icmpConn
+
+	mu      sync.Mutex
+	pending map[echoKey]chan time.Time
+}
+
+// icmpConn is an ICMP socket for one address family.
+type icmpConn struct {
+	conn     *icmp.PacketConn
+	proto    int
+	echoType icmp.Type
+	replType icmp.Type
+	datagram bool // the kernel owns the echo ID of datagram sockets
+	closed   atomic.Bool
+}
+
+// echoKey identifies an outstanding echo request.
+type echoKey struct {
+	addr netip.Addr
+	seq  int
+}
+
+// NewICMPProber opens the ICMP sockets used for probing.
+// It fails only if neither an IPv4 nor an IPv6 socket could be opened.
+// The returned prober must be closed when no longer needed.
+func NewICMPProber() (*ICMPProber, error) {
+	p := &ICMPProber{
+		id:      os.Getpid() & 0xffff,
+		pending: make(map[echoKey]chan time.Time),
+	}
+
+	var err4, err6 error
+	p.v4, err4 = listenICMP("udp4", "ip4:icmp", "0.0.0.0", protocolICMP,
+		ipv4.ICMPTypeEcho, ipv4.ICMPTypeEchoReply)
+	p.v6, err6 = listenICMP("udp6", "ip6:ipv6-icmp", "::", protocolIPv6ICMP,
+		ipv6.ICMPTypeEchoRequest, ipv6.ICMPTypeEchoReply)
+	if p.v4 == nil && p.v6 == nil {
+		return nil, fmt.Errorf("%w: %w", ErrICMPUnavailable, errors.Join(err4, err6))
+	}
+
+	for _, c := range []*icmpConn{p.v4, p.v6} {
+		if c != nil {
+			go p.readReplies(c)
+		}
+	}
+
+	return p, nil
+}
+
+// listenICMP opens an unprivileged datagram socket, falling back to a raw
+// socket if the former is not permitted.
+func listenICMP(datagram, raw, address string, proto int, echoType, replType icmp.Type) (*icmpConn, error) {
+	c := &icmpConn{
+		proto:    proto,
+		echoType: echoType,
+		replType: replType,
+		datagram: true,
+	}
+
+	conn, err := icmp.ListenPacket(datagram, address)
+	if err != nil {
+		var rawErr error
+		conn, rawErr = icmp.ListenPacket(raw, address)
+		if rawErr != nil {
+			return nil, errors.Join(err, rawErr)
+		}
+		c.datagram = false
+	}
+	c.conn = conn
+
+	return c, nil
+}
+
+// Close closes the prober's sockets. Probes in flight fail once their
+// context is done.
+func (p *ICMPProber) Close() error {
+	var errs []error
+	for _, c := range []*icmpConn{p.v4, p.v6} {
+		if c != nil {
+			c.closed.Store(true)
+			errs = append(errs, c.conn.Close())
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Probe sends one echo request to ip and waits for the matching reply
+// until the context is done.
+func (p *ICMPProber) Probe(ctx context.Context, ip net.IP) (ProbeResult, error) {
+	res := ProbeResult{Method: "icmp"}
+
+	addr, ok := netip.AddrFromSlice(ip)
+	if !ok {
+		return res, fmt.Errorf("invalid IP address: %v", ip)
+	}
+	addr = addr.Unmap()
+
+	c := p.v4
+	if addr.Is6() {
+		c = p.v6
+	}
+	if c == nil {
+		return res, fmt.Errorf("%w for %v", ErrICMPUnavailable, addr)
+	}
+
+	key := echoKey{addr: addr, seq: int(uint16(p.seq.Add(1)))}
+	reply := make(chan time.Time, 1)
+	p.mu.Lock()
+	p.pending[key] = reply
+	p.mu.Unlock()
+	defer func() {
+		p.mu.Lock()
+		delete(p.pending, key)
+		p.mu.Unlock()
+	}()
+
+	msg := icmp.Message{
+		Type: c.echoType,
+		Body: &icmp.Echo{
+			ID:   p.id,
+			Seq:  key.seq,
+			Data: []byte("hostscanner"),
+		},
+	}
+	b, err := msg.Marshal(nil)
+	if err != nil {
+		return res, err
+	}
+
+	var dst net.Addr = &net.IPAddr{IP: addr.AsSlice(), Zone: addr.Zone()}
+	if c.datagram {
+		dst = &net.UDPAddr{IP: addr.AsSlice(), Zone: addr.Zone()}
+	}
+
+	start := time.Now()
+	if _, err := c.conn.WriteTo(b, dst); err != nil {
+		return res, err
+	}
+
+	select {
+	case received := <-reply:
+		res.Alive = true
+		res.Latency = received.Sub(start)
+		res.Evidence = "icmp echo reply"
+		return res, nil
+	case <-ctx.Done():
+		res.Latency = time.Since(start)
+		return res, ctx.Err()
+	}
+}
+
+// readReplies dispatches echo replies read from c to the waiting probes
+// until the socket is closed.
+func (p *ICMPProber) readReplies(c *icmpConn) {
+	buf := make([]byte, 1500)
+	for {
+		n, peer, err := c.conn.ReadFrom(buf)
+		if err != nil {
+			if c.closed.Load() {
+				return
+			}
+			continue
+		}
+		received := time.Now()
+
+		msg, err := icmp.ParseMessage(c.proto, buf[:n])
+		if err != nil || msg.Type != c.replType {
+			continue
+		}
+		echo, ok := msg.Body.(*icmp.Echo)
+		if !ok || (!c.datagram && echo.ID != p.id) {
+			continue
+		}
+
+		var addr netip.Addr
+		switch peer := peer.(type) {
+		case *net.IPAddr:
+			addr, _ = netip.AddrFromSlice(peer.IP)
+		case *net.UDPAddr:
+			addr, _ = netip.AddrFromSlice(peer.IP)
+		}
+		key := echoKey{addr: addr.Unmap(), seq: echo.Seq}
+
+		p.mu.Lock()
+		if reply, ok := p.pending[key]; ok {
+			select {
+			case reply <- received:
+			default:
+			}
+		}
+		p.mu.Unlock()
+	}
+}
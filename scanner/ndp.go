@@ -0,0 +1,551 @@
+package scanner
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv6"
+)
+
+// Neighbor Discovery option types (RFC 4861).
+const (
+	ndpOptSourceLinkAddr = 1
+	ndpOptTargetLinkAddr = 2
+)
+
+// ndpHopLimit is the hop limit Neighbor Discovery messages must carry to
+// be accepted.
+const ndpHopLimit = 255
+
+// allNodes is the link-local all-nodes multicast group.
+var allNodes = netip.MustParseAddr("ff02::1")
+
+// DiscoverIPv6 finds the IPv6 hosts on the link of iface without probing
+// every address, which is impossible for a /64. It sends ICMPv6 echo
+// requests to the all-nodes group from each of the interface's addresses
+// and Neighbor Solicitations to every responder and to the IPv6 entries
+// of the kernel neighbour table, listens until the timeout, and merges the
+// answers with the neighbour table. Hosts are grouped by MAC address, with
+// their global and link-local addresses in Addresses. Neighbour table
+// entries that did not answer are reported as offline.
+//
+// WithRetries, WithReverseDNS, WithHostFunc and WithProgressFunc apply;
+// the other options are ignored. Progress counts the rounds of echo
+// requests, each lasting the timeout, in Done and Total, and the addresses
+// that answered so far in Alive; the last report gives the hosts found.
+// Opening the raw ICMPv6 socket requires root or CAP_NET_RAW.
+func DiscoverIPv6(ctx context.Context, iface *net.Interface, timeout time.Duration, opts ...Option) (*ScanResult, error) {
+	cfg := applyOptions(timeout, opts)
+	start := time.Now()
+
+	local := interfaceAddrs6(iface)
+	linkLocal := slices.IndexFunc(local, netip.Addr.IsLinkLocalUnicast)
+	if linkLocal < 0 {
+		return nil, fmt.Errorf("%w: %s has no IPv6 link-local address", ErrNotOnLink, iface.Name)
+	}
+
+	conn, err := icmp.ListenPacket("ip6:ipv6-icmp", "::")
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrICMPUnavailable, err)
+	}
+	pc := conn.IPv6PacketConn()
+	if err := pc.SetControlMessage(ipv6.FlagInterface, true); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to configure ICMPv6 socket: %w", err)
+	}
+
+	d := &ndpDiscovery{
+		iface:      iface,
+		conn:       pc,
+		id:         os.Getpid() & 0xffff,
+		local:      local,
+		linkLocal:  local[linkLocal],
+		responders: make(map[netip.Addr]*ndpResponder),
+		solicited:  make(map[netip.Addr]time.Time),
+		learned:    make(map[netip.Addr]string),
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		d.readReplies()
+	}()
+
+	// Known neighbours are asked directly whether they are still there
+	cached := d.cachedNeighbors()
+	for _, n := range cached {
+		d.solicit(n.addr)
+	}
+
+	// Each round sends echo requests and listens for the timeout
+	rounds, completed := cfg.retries+1, 0
+	progress := func() Progress {
+		return Progress{
+			Done:    completed,
+			Total:   rounds,
+			Alive:   d.answered(),
+			Elapsed: time.Since(start),
+		}
+	}
+	var tick <-chan time.Time
+	if cfg.onProgress != nil && cfg.progressInterval > 0 {
+		ticker := time.NewTicker(cfg.progressInterval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	for completed < rounds && ctx.Err() == nil {
+		d.sendEchoes()
+		timer := time.NewTimer(cfg.timeout)
+	listen:
+		for {
+			select {
+			case <-timer.C:
+				completed++
+				break listen
+			case <-ctx.Done():
+				timer.Stop()
+				break listen
+			case <-tick:
+				cfg.onProgress(progress())
+			}
+		}
+	}
+	conn.Close()
+	<-done
+
+	// Answers to the echo requests filled the neighbour table further
+	cached = append(cached, d.cachedNeighbors()...)
+	hosts := d.hosts(cached)
+
+	result := &ScanResult{
+		TotalHosts: len(hosts),
+		Hosts:      make([]Host, 0, len(hosts)),
+		StartTime:  start,
+	}
+	for _, host := range hosts {
+		if host.IsAlive && cfg.reverseDNS {
+			for _, ip := range host.Addresses {
+				host.Names = append(host.Names, lookupNames(ctx, ip)...)
+			}
+			if len(host.Names) > 0 {
+				host.Hostname = host.Names[0]
+			}
+		}
+
+		result.Hosts = append(result.Hosts, host)
+		if host.IsAlive {
+			result.AliveHosts++
+		}
+		if cfg.onHost != nil {
+			cfg.onHost(host)
+		}
+	}
+
+	result.Cancelled = ctx.Err() != nil
+	result.ScanTime = time.Since(start)
+	if cfg.onProgress != nil {
+		p := progress()
+		p.Alive = result.AliveHosts
+		cfg.onProgress(p)
+	}
+
+	return result, nil
+}
+
+// ndpDiscovery holds the state of a DiscoverIPv6 run.
+type ndpDiscovery struct {
+	iface     *net.Interface
+	conn      *ipv6.PacketConn
+	id        int
+	local     []netip.Addr // the interface's own addresses
+	linkLocal netip.Addr
+
+	mu         sync.Mutex
+	echoSent   []time.Time // when each echo round was sent, by sequence number
+	responders map[netip.Addr]*ndpResponder
+	solicited  map[netip.Addr]time.Time // when each Neighbor Solicitation was sent
+	learned    map[netip.Addr]string    // MAC addresses announced in solicitations
+}
+
+// ndpResponder is an address found during discovery.
+type ndpResponder struct {
+	mac      string
+	alive    bool // false for neighbour table entries that did not answer
+	latency  time.Duration
+	evidence string
+}
+
+// ndpNeighbor is an IPv6 entry of the kernel neighbour table.
+type ndpNeighbor struct {
+	addr  netip.Addr
+	mac   string
+	state NeighborState
+}
+
+// interfaceAddrs6 returns the unicast IPv6 addresses of iface.
+func interfaceAddrs6(iface *net.Interface) []netip.Addr {
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return nil
+	}
+
+	var local []netip.Addr
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if !ok || ipNet.IP.To4() != nil {
+			continue
+		}
+		if a, ok := netip.AddrFromSlice(ipNet.IP); ok && (a.IsGlobalUnicast() || a.IsLinkLocalUnicast()) {
+			local = append(local, a)
+		}
+	}
+
+	return local
+}
+
+// sendEchoes sends a round of echo requests to the all-nodes group, one
+// from each local address, so hosts answer from the address of the
+// matching scope. The sequence number identifies the round.
+func (d *ndpDiscovery) sendEchoes() {
+	d.mu.Lock()
+	seq := len(d.echoSent)
+	d.echoSent = append(d.echoSent, time.Time{})
+	d.mu.Unlock()
+
+	msg := icmp.Message{
+		Type: ipv6.ICMPTypeEchoRequest,
+		Body: &icmp.Echo{
+			ID:   d.id,
+			Seq:  seq,
+			Data: []byte("hostscanner"),
+		},
+	}
+	b, err := msg.Marshal(nil)
+	if err != nil {
+		return
+	}
+
+	d.mu.Lock()
+	d.echoSent[seq] = time.Now()
+	d.mu.Unlock()
+	for _, src := range d.local {
+		d.write(b, src, allNodes)
+	}
+}
+
+// solicit sends a Neighbor Solicitation for target to its solicited-node
+// multicast group, unless one was sent already.
+func (d *ndpDiscovery) solicit(target netip.Addr) {
+	d.mu.Lock()
+	if _, ok := d.solicited[target]; ok {
+		d.mu.Unlock()
+		return
+	}
+	d.solicited[target] = time.Now()
+	d.mu.Unlock()
+
+	msg := icmp.Message{
+		Type: ipv6.ICMPTypeNeighborSolicitation,
+		Body: &icmp.RawBody{Data: marshalNeighborSolicitation(target, d.iface.HardwareAddr)},
+	}
+	b, err := msg.Marshal(nil)
+	if err != nil {
+		return
+	}
+	d.write(b, d.linkLocal, solicitedNode(target))
+}
+
+// write sends an ICMPv6 message from src to dst on the interface. The
+// kernel fills in the checksum. The zone is given by index, as the net
+// package caches zone names and may map a recreated interface's name to
+// its old index.
+func (d *ndpDiscovery) write(b []byte, src, dst netip.Addr) {
+	cm := &ipv6.ControlMessage{
+		HopLimit: ndpHopLimit,
+		Src:      src.AsSlice(),
+		IfIndex:  d.iface.Index,
+	}
+	zone := strconv.Itoa(d.iface.Index)
+	d.conn.WriteTo(b, cm, &net.IPAddr{IP: dst.AsSlice(), Zone: zone})
+}
+
+// readReplies records the hosts that answer until the socket is closed.
+// Echo replies reveal an address and Neighbor Advertisements its MAC
+// address. Hosts also announce their MAC address in the Neighbor
+// Solicitations they send to learn ours before replying.
+func (d *ndpDiscovery) readReplies() {
+	buf := make([]byte, 1500)
+	for {
+		n, cm, peer, err := d.conn.ReadFrom(buf)
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			continue
+		}
+		received := time.Now()
+		if cm != nil && cm.IfIndex != d.iface.Index {
+			continue
+		}
+		src, ok := peerAddr(peer)
+		if !ok {
+			continue
+		}
+
+		msg, err := icmp.ParseMessage(protocolIPv6ICMP, buf[:n])
+		if err != nil {
+			continue
+		}
+		switch msg.Type {
+		case ipv6.ICMPTypeEchoReply:
+			echo, ok := msg.Body.(*icmp.Echo)
+			if !ok || echo.ID != d.id {
+				continue
+			}
+			// Replies are timed from the round they answer
+			d.mu.Lock()
+			var latency time.Duration
+			if echo.Seq < len(d.echoSent) && !d.echoSent[echo.Seq].IsZero() {
+				latency = received.Sub(d.echoSent[echo.Seq])
+			}
+			d.mu.Unlock()
+			if d.record(src, "", latency, "icmpv6 echo reply to "+allNodes.String()) {
+				d.solicit(src)
+			}
+		case ipv6.ICMPTypeNeighborSolicitation:
+			body, ok := msg.Body.(*icmp.RawBody)
+			if !ok || !src.IsValid() || src.IsUnspecified() {
+				continue
+			}
+			if _, mac, ok := parseNeighborMessage(body.Data, ndpOptSourceLinkAddr); ok {
+				d.mu.Lock()
+				d.learned[src] = mac
+				d.mu.Unlock()
+			}
+		case ipv6.ICMPTypeNeighborAdvertisement:
+			body, ok := msg.Body.(*icmp.RawBody)
+			if !ok {
+				continue
+			}
+			target, mac, ok := parseNeighborMessage(body.Data, ndpOptTargetLinkAddr)
+			if !ok {
+				continue
+			}
+			d.mu.Lock()
+			sent, solicited := d.solicited[target]
+			d.mu.Unlock()
+			var latency time.Duration
+			if solicited {
+				latency = received.Sub(sent)
+			}
+			d.record(target, mac, latency, "neighbor advertisement")
+		}
+	}
+}
+
+// answered returns the number of addresses that answered so far.
+func (d *ndpDiscovery) answered() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return len(d.responders)
+}
+
+// record notes that addr answered. It reports whether addr is new.
+func (d *ndpDiscovery) record(addr netip.Addr, mac string, latency time.Duration, evidence string) bool {
+	if slices.Contains(d.local, addr) || !(addr.IsGlobalUnicast() || addr.IsLinkLocalUnicast()) {
+		return false
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	r, ok := d.responders[addr]
+	if !ok {
+		d.responders[addr] = &ndpResponder{mac: mac, alive: true, latency: latency, evidence: evidence}
+		return true
+	}
+	if r.mac == "" {
+		r.mac = mac
+	}
+	if r.latency == 0 {
+		r.latency = latency
+	}
+
+	return false
+}
+
+// cachedNeighbors returns the resolved IPv6 entries of the kernel
+// neighbour table on the interface.
+func (d *ndpDiscovery) cachedNeighbors() []ndpNeighbor {
+	neighbors, err := ReadNeighbors()
+	if err != nil {
+		return nil
+	}
+
+	var cached []ndpNeighbor
+	for _, n := range neighbors {
+		addr, ok := netip.AddrFromSlice(n.IP)
+		if !ok || addr.Is4() || n.Interface != d.iface.Name || !n.State.Resolved() || n.MAC == "" {
+			continue
+		}
+		if n.State == NeighborNoARP || slices.Contains(d.local, addr) ||
+			!(addr.IsGlobalUnicast() || addr.IsLinkLocalUnicast()) {
+			continue
+		}
+		cached = append(cached, ndpNeighbor{addr: addr, mac: n.MAC, state: n.State})
+	}
+
+	return cached
+}
+
+// hosts groups the responders, the neighbour table entries and the local
+// interface into one host per MAC address. Addresses whose MAC address is
+// unknown become hosts of their own.
+func (d *ndpDiscovery) hosts(cached []ndpNeighbor) []Host {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for addr, mac := range d.learned {
+		if r, ok := d.responders[addr]; ok && r.mac == "" {
+			r.mac = mac
+		}
+	}
+	for _, n := range cached {
+		if r, ok := d.responders[n.addr]; ok {
+			if r.mac == "" {
+				r.mac = n.mac
+			}
+			continue
+		}
+		d.responders[n.addr] = &ndpResponder{mac: n.mac, evidence: "neighbour table " + string(n.state)}
+	}
+
+	var hosts []Host
+	byMAC := make(map[string]int)
+	for addr, r := range d.responders {
+		i, ok := byMAC[r.mac]
+		if !ok || r.mac == "" {
+			i = len(hosts)
+			hosts = append(hosts, Host{MAC: r.mac, Method: "ndp"})
+			if r.mac != "" {
+				byMAC[r.mac] = i
+				hosts[i].Vendor = getVendorFromMAC(r.mac)
+			}
+		}
+
+		host := &hosts[i]
+		host.Addresses = append(host.Addresses, net.IP(addr.AsSlice()))
+		if r.alive && (!host.IsAlive || r.latency < host.Latency) {
+			host.Latency = r.latency
+		}
+		if (r.alive && !host.IsAlive) || host.Evidence == "" {
+			host.Evidence = r.evidence
+		}
+		host.IsAlive = host.IsAlive || r.alive
+	}
+
+	if len(d.iface.HardwareAddr) > 0 {
+		self := Host{
+			MAC:      strings.ToUpper(d.iface.HardwareAddr.String()),
+			IsAlive:  true,
+			Method:   "ndp",
+			Evidence: "local interface " + d.iface.Name,
+		}
+		self.Vendor = getVendorFromMAC(self.MAC)
+		for _, addr := range d.local {
+			self.Addresses = append(self.Addresses, net.IP(addr.AsSlice()))
+		}
+		hosts = append(hosts, self)
+	}
+
+	for i := range hosts {
+		slices.SortFunc(hosts[i].Addresses, compareAddresses)
+		hosts[i].IP = hosts[i].Addresses[0]
+	}
+	slices.SortFunc(hosts, func(a, b Host) int {
+		return compareAddresses(a.IP, b.IP)
+	})
+
+	return hosts
+}
+
+// compareAddresses orders global addresses before link-local ones, and
+// each in ascending order.
+func compareAddresses(a, b net.IP) int {
+	if la, lb := a.IsLinkLocalUnicast(), b.IsLinkLocalUnicast(); la != lb {
+		if la {
+			return 1
+		}
+		return -1
+	}
+	return bytes.Compare(a.To16(), b.To16())
+}
+
+// peerAddr returns the address of a packet's sender without its zone.
+func peerAddr(peer net.Addr) (netip.Addr, bool) {
+	ipAddr, ok := peer.(*net.IPAddr)
+	if !ok {
+		return netip.Addr{}, false
+	}
+	addr, ok := netip.AddrFromSlice(ipAddr.IP)
+	return addr.Unmap(), ok
+}
+
+// solicitedNode returns the solicited-node multicast group of addr,
+// ff02::1:ffXX:XXXX with the low 24 bits of addr.
+func solicitedNode(addr netip.Addr) netip.Addr {
+	a := addr.As16()
+	return netip.AddrFrom16([16]byte{
+		0: 0xff, 1: 0x02, 11: 0x01, 12: 0xff,
+		13: a[13], 14: a[14], 15: a[15],
+	})
+}
+
+// marshalNeighborSolicitation returns the body of a Neighbor Solicitation
+// for target, announcing mac as the sender's link-layer address.
+func marshalNeighborSolicitation(target netip.Addr, mac net.HardwareAddr) []byte {
+	b := make([]byte, 20, 28)
+	t := target.As16()
+	copy(b[4:20], t[:])
+	if len(mac) == 6 {
+		b = append(b, ndpOptSourceLinkAddr, 1)
+		b = append(b, mac...)
+	}
+
+	return b
+}
+
+// parseNeighborMessage parses the body of a Neighbor Solicitation or
+// Advertisement and returns its target address and the link-layer address
+// in the option of type opt.
+func parseNeighborMessage(b []byte, opt byte) (netip.Addr, string, bool) {
+	if len(b) < 20 {
+		return netip.Addr{}, "", false
+	}
+	target := netip.AddrFrom16([16]byte(b[4:20]))
+
+	options := b[20:]
+	for len(options) >= 8 {
+		length := int(options[1]) * 8
+		if length == 0 || length > len(options) {
+			break
+		}
+		if options[0] == opt && length == 8 {
+			return target, strings.ToUpper(net.HardwareAddr(options[2:8]).String()), true
+		}
+		options = options[length:]
+	}
+
+	return target, "", false
+}
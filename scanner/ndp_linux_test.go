@@ -0,0 +1,90 @@
+package scanner_test
+
+import (
+	"context"
+	"net"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"hostscanner/scanner"
+)
+
+func TestDiscoverIPv6_Veth(t *testing.T) {
+	iface, peerMAC := setupVeth(t)
+
+	const ns, peer = "hostscanner-test", "hsveth1"
+	steps := [][]string{
+		{"addr", "add", "fd00:203::1/64", "dev", iface.Name, "nodad"},
+		{"-n", ns, "addr", "add", "fd00:203::2/64", "dev", peer, "nodad"},
+	}
+	for _, step := range steps {
+		if out, err := exec.Command("ip", step...).CombinedOutput(); err != nil {
+			t.Skipf("Could not add IPv6 addresses: %v: %s", err, out)
+		}
+	}
+
+	// Link-local addresses cannot be used before duplicate address
+	// detection finishes.
+	tentative := func() bool {
+		local, _ := exec.Command("ip", "-6", "addr", "show", "dev", iface.Name, "tentative").Output()
+		remote, _ := exec.Command("ip", "-n", ns, "-6", "addr", "show", "dev", peer, "tentative").Output()
+		return len(strings.TrimSpace(string(local))) > 0 || len(strings.TrimSpace(string(remote))) > 0
+	}
+	require.Eventually(t, func() bool { return !tentative() }, 5*time.Second, 100*time.Millisecond)
+
+	out, err := exec.Command("ip", "-n", ns, "-6", "addr", "show", "dev", peer, "scope", "link").Output()
+	require.NoError(t, err)
+	var peerLinkLocal net.IP
+	for _, field := range strings.Fields(string(out)) {
+		if ip, _, err := net.ParseCIDR(field); err == nil {
+			peerLinkLocal = ip
+		}
+	}
+	require.NotNil(t, peerLinkLocal)
+
+	var (
+		streamed int
+		reports  []scanner.Progress
+	)
+	result, err := scanner.DiscoverIPv6(context.Background(), iface, 300*time.Millisecond,
+		scanner.WithReverseDNS(false),
+		scanner.WithRetries(1),
+		scanner.WithHostFunc(func(scanner.Host) { streamed++ }),
+		scanner.WithProgressFunc(50*time.Millisecond, func(p scanner.Progress) { reports = append(reports, p) }))
+	require.NoError(t, err)
+	assert.Equal(t, len(result.Hosts), streamed)
+
+	// Progress is reported while listening, counting rounds of echoes
+	require.Greater(t, len(reports), 2)
+	assert.Equal(t, 0, reports[0].Done)
+	last := reports[len(reports)-1]
+	assert.Equal(t, 2, last.Done)
+	assert.Equal(t, 2, last.Total)
+	assert.Equal(t, result.AliveHosts, last.Alive)
+
+	var found, self *scanner.Host
+	for i := range result.Hosts {
+		switch result.Hosts[i].MAC {
+		case peerMAC:
+			found = &result.Hosts[i]
+		case strings.ToUpper(iface.HardwareAddr.String()):
+			self = &result.Hosts[i]
+		}
+	}
+	if assert.NotNil(t, found) {
+		assert.True(t, found.IsAlive)
+		assert.Equal(t, "ndp", found.Method)
+		assert.Equal(t, "icmpv6 echo reply to ff02::1", found.Evidence)
+		assert.Equal(t, "fd00:203::2", found.IP.String())
+		assert.Equal(t, []net.IP{net.ParseIP("fd00:203::2"), peerLinkLocal}, found.Addresses)
+		assert.Positive(t, found.Latency)
+		assert.Less(t, found.Latency, 300*time.Millisecond)
+	}
+	if assert.NotNil(t, self) {
+		assert.Equal(t, "local interface "+iface.Name, self.Evidence)
+	}
+}
@@ -0,0 +1,156 @@
+package scanner
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// NeighborState is the state of an entry in the kernel neighbour table.
+type NeighborState string
+
+// Neighbour table entry states, named as by `ip neigh`.
+const (
+	NeighborIncomplete NeighborState = "INCOMPLETE"
+	NeighborReachable  NeighborState = "REACHABLE"
+	NeighborStale      NeighborState = "STALE"
+	NeighborDelay      NeighborState = "DELAY"
+	NeighborProbe      NeighborState = "PROBE"
+	NeighborFailed     NeighborState = "FAILED"
+	NeighborNoARP      NeighborState = "NOARP"
+	NeighborPermanent  NeighborState = "PERMANENT"
+	// NeighborComplete marks a resolved entry whose exact state is not
+	// known, as reported by /proc/net/arp.
+	NeighborComplete NeighborState = "COMPLETE"
+)
+
+// Resolved reports whether entries in state s carry a usable MAC address.
+func (s NeighborState) Resolved() bool {
+	switch s {
+	case NeighborIncomplete, NeighborFailed, "":
+		return false
+	}
+	return true
+}
+
+// Neighbor is an entry of the kernel neighbour (ARP or NDP) table.
+type Neighbor struct {
+	IP        net.IP
+	MAC       string
+	Interface string
+	State     NeighborState
+}
+
+// ATF_* flags used in /proc/net/arp.
+const (
+	atfComplete  = 0x2
+	atfPermanent = 0x4
+)
+
+// ParseProcNetARP parses the IPv4 neighbour table in the format of
+// /proc/net/arp.
+func ParseProcNetARP(r io.Reader) ([]Neighbor, error) {
+	var neighbors []Neighbor
+
+	s := bufio.NewScanner(r)
+	s.Scan() // header line
+	for s.Scan() {
+		// IP address, HW type, Flags, HW address, Mask, Device
+		fields := strings.Fields(s.Text())
+		if len(fields) < 6 {
+			continue
+		}
+
+		ip := net.ParseIP(fields[0])
+		flags, err := strconv.ParseUint(fields[2], 0, 32)
+		if ip == nil || err != nil {
+			return nil, fmt.Errorf("malformed ARP table line: %q", s.Text())
+		}
+
+		n := Neighbor{
+			IP:        ip,
+			Interface: fields[5],
+			State:     NeighborIncomplete,
+		}
+		switch {
+		case flags&atfPermanent != 0:
+			n.State = NeighborPermanent
+		case flags&atfComplete != 0:
+			n.State = NeighborComplete
+		}
+		if n.State.Resolved() {
+			n.MAC = strings.ToUpper(fields[3])
+		}
+
+		neighbors = append(neighbors, n)
+	}
+
+	return neighbors, s.Err()
+}
+
+// neighborCache answers MAC address lookups for a scan from the kernel
+// neighbour table. The table is read once and only read again when a host
+// that answered after the last read is missing from it.
+type neighborCache struct {
+	mu          sync.Mutex
+	read        time.Time
+	macs        map[string]string
+	unsupported bool
+}
+
+// lookupMAC returns the MAC address of ip, whose probe completed at
+// answered. Where the neighbour table cannot be read it falls back to the
+// arp command.
+func (c *neighborCache) lookupMAC(ctx context.Context, ip net.IP, answered time.Time) string {
+	key := ip.String()
+
+	c.mu.Lock()
+	mac, err := c.lookup(key, answered)
+	c.mu.Unlock()
+
+	// The arp command runs without the lock so lookups stay concurrent
+	if errors.Is(err, ErrUnsupportedOS) {
+		return getMACAddress(ctx, key)
+	}
+
+	return mac
+}
+
+// lookup returns the MAC address of the host at key, reading the neighbour
+// table again if the host is missing and the table was read before the
+// host answered, by which time the kernel had resolved its entry. c.mu must
+// be held.
+func (c *neighborCache) lookup(key string, answered time.Time) (string, error) {
+	if c.unsupported {
+		return "", ErrUnsupportedOS
+	}
+	if mac, ok := c.macs[key]; ok || c.read.After(answered) {
+		return mac, nil
+	}
+
+	read := time.Now()
+	neighbors, err := ReadNeighbors()
+	if errors.Is(err, ErrUnsupportedOS) {
+		c.unsupported = true
+	}
+	if err != nil {
+		return "", err
+	}
+
+	c.read = read
+	c.macs = make(map[string]string, len(neighbors))
+	for _, n := range neighbors {
+		if n.State.Resolved() && n.MAC != "" {
+			c.macs[n.IP.String()] = n.MAC
+		}
+	}
+
+	return c.macs[key], nil
+}
@@ -0,0 +1,145 @@
+package scanner
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// procNetARP is the kernel's text view of the IPv4 neighbour table.
+const procNetARP = "/proc/net/arp"
+
+// nudStates maps the kernel's NUD_* bits to neighbour states.
+var nudStates = []struct {
+	nud   uint16
+	state NeighborState
+}{
+	{unix.NUD_INCOMPLETE, NeighborIncomplete},
+	{unix.NUD_REACHABLE, NeighborReachable},
+	{unix.NUD_STALE, NeighborStale},
+	{unix.NUD_DELAY, NeighborDelay},
+	{unix.NUD_PROBE, NeighborProbe},
+	{unix.NUD_FAILED, NeighborFailed},
+	{unix.NUD_NOARP, NeighborNoARP},
+	{unix.NUD_PERMANENT, NeighborPermanent},
+}
+
+// ReadNeighbors returns the kernel neighbour table for IPv4 and IPv6.
+// It queries RTM_GETNEIGH over netlink and falls back to /proc/net/arp,
+// which only lists IPv4 entries, if netlink is not available.
+func ReadNeighbors() ([]Neighbor, error) {
+	neighbors, err := readNeighborsNetlink()
+	if err == nil {
+		return neighbors, nil
+	}
+
+	f, procErr := os.Open(procNetARP)
+	if procErr != nil {
+		return nil, fmt.Errorf("failed to read neighbour table: %w", errors.Join(err, procErr))
+	}
+	defer f.Close()
+
+	return ParseProcNetARP(f)
+}
+
+// readNeighborsNetlink dumps the neighbour table over netlink.
+func readNeighborsNetlink() ([]Neighbor, error) {
+	rib, err := syscall.NetlinkRIB(unix.RTM_GETNEIGH, unix.AF_UNSPEC)
+	if err != nil {
+		return nil, fmt.Errorf("RTM_GETNEIGH: %w", err)
+	}
+
+	msgs, err := syscall.ParseNetlinkMessage(rib)
+	if err != nil {
+		return nil, fmt.Errorf("RTM_GETNEIGH: %w", err)
+	}
+
+	ifaceNames := make(map[int32]string)
+	var neighbors []Neighbor
+	for _, m := range msgs {
+		if m.Header.Type != unix.RTM_NEWNEIGH {
+			continue
+		}
+
+		n, ifindex, ok := parseNeighMessage(m.Data)
+		if !ok {
+			continue
+		}
+
+		name, seen := ifaceNames[ifindex]
+		if !seen {
+			if iface, err := net.InterfaceByIndex(int(ifindex)); err == nil {
+				name = iface.Name
+			}
+			ifaceNames[ifindex] = name
+		}
+		n.Interface = name
+
+		neighbors = append(neighbors, n)
+	}
+
+	return neighbors, nil
+}
+
+// parseNeighMessage decodes the payload of an RTM_NEWNEIGH message: a
+// struct ndmsg followed by route attributes.
+func parseNeighMessage(b []byte) (Neighbor, int32, bool) {
+	if len(b) < unix.SizeofNdMsg {
+		return Neighbor{}, 0, false
+	}
+
+	family := b[0]
+	if family != unix.AF_INET && family != unix.AF_INET6 {
+		return Neighbor{}, 0, false
+	}
+	ifindex := int32(binary.NativeEndian.Uint32(b[4:8]))
+	nud := binary.NativeEndian.Uint16(b[8:10])
+
+	var n Neighbor
+	for _, s := range nudStates {
+		if nud&s.nud != 0 {
+			n.State = s.state
+			break
+		}
+	}
+
+	attrs := b[unix.SizeofNdMsg:]
+	for len(attrs) >= unix.SizeofRtAttr {
+		length := int(binary.NativeEndian.Uint16(attrs[0:2]))
+		kind := binary.NativeEndian.Uint16(attrs[2:4])
+		if length < unix.SizeofRtAttr || length > len(attrs) {
+			break
+		}
+		value := attrs[unix.SizeofRtAttr:length]
+
+		switch kind {
+		case unix.NDA_DST:
+			n.IP = net.IP(append([]byte(nil), value...))
+		case unix.NDA_LLADDR:
+			// Loopback entries, such as ::1, carry an all-zero address
+			if len(value) == 6 && !bytes.Equal(value, make([]byte, 6)) {
+				n.MAC = strings.ToUpper(net.HardwareAddr(value).String())
+			}
+		}
+
+		// Attributes are padded to 4-byte boundaries
+		aligned := (length + unix.RTA_ALIGNTO - 1) &^ (unix.RTA_ALIGNTO - 1)
+		if aligned > len(attrs) {
+			break
+		}
+		attrs = attrs[aligned:]
+	}
+
+	if n.IP == nil {
+		return Neighbor{}, 0, false
+	}
+
+	return n, ifindex, true
+}
@@ -0,0 +1,46 @@
+package scanner_test
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"hostscanner/scanner"
+)
+
+func TestReadNeighbors_Veth(t *testing.T) {
+	iface, peerMAC := setupVeth(t)
+
+	// Resolving the peer for an ICMP probe creates its neighbour entry.
+	prober, err := scanner.NewICMPProber()
+	require.NoError(t, err)
+	defer prober.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	res, err := prober.Probe(ctx, net.ParseIP("10.203.0.2"))
+	require.NoError(t, err)
+	require.True(t, res.Alive)
+
+	neighbors, err := scanner.ReadNeighbors()
+	require.NoError(t, err)
+
+	var found *scanner.Neighbor
+	for i := range neighbors {
+		if neighbors[i].IP.Equal(net.ParseIP("10.203.0.2")) {
+			found = &neighbors[i]
+		}
+	}
+	if assert.NotNil(t, found) {
+		assert.Equal(t, peerMAC, found.MAC)
+		assert.Equal(t, iface.Name, found.Interface)
+		assert.Equal(t, scanner.NeighborReachable, found.State)
+	}
+
+	// The scanner fills in the MAC address from the neighbour table.
+	result := scanner.ScanNetwork([]net.IP{net.ParseIP("10.203.0.2")}, time.Second, 1,
+		scanner.WithProber(prober))
+	assert.Equal(t, peerMAC, result.Hosts[0].MAC)
+}
@@ -0,0 +1,14 @@
+//go:build !linux
+
+package scanner
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// ReadNeighbors returns the kernel neighbour table.
+// It is only supported on Linux.
+func ReadNeighbors() ([]Neighbor, error) {
+	return nil, fmt.Errorf("%w: %s", ErrUnsupportedOS, runtime.GOOS)
+}
@@ -0,0 +1,169 @@
+package scanner
+
+import (
+	"io"
+	"net"
+	"time"
+)
+
+// Option configures a network scan.
+type Option func(*scanConfig)
+
+// scanConfig holds the settings shared by all workers of a scan.
+type scanConfig struct {
+	timeout          time.Duration
+	retries          int
+	reverseDNS       bool
+	dropOffline      bool
+	icmp             bool
+	probers          []Prober
+	tcpPorts         []int
+	arpIface         *net.Interface
+	arpLocal         *net.IPNet
+	onHost           func(Host)
+	onProgress       func(Progress)
+	progressInterval time.Duration
+	neighbors        *neighborCache
+	closers          []io.Closer
+}
+
+// applyOptions applies opts on top of the default configuration.
+func applyOptions(timeout time.Duration, opts []Option) *scanConfig {
+	cfg := &scanConfig{
+		timeout:    timeout,
+		reverseDNS: true,
+		icmp:       true,
+		neighbors:  &neighborCache{},
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	return cfg
+}
+
+// newScanConfig applies opts on top of the default configuration and opens
+// the probers of the scan.
+func newScanConfig(timeout time.Duration, opts []Option) *scanConfig {
+	cfg := applyOptions(timeout, opts)
+
+	var extra []Prober
+	if len(cfg.tcpPorts) > 0 {
+		extra = append(extra, TCPProber{Ports: cfg.tcpPorts})
+	}
+	if cfg.arpIface != nil {
+		// ARP needs raw socket privileges; scan without it otherwise.
+		if p, err := NewARPProber(cfg.arpIface, cfg.arpLocal); err == nil {
+			cfg.closers = append(cfg.closers, p)
+			extra = append(extra, p)
+		}
+	}
+
+	// The default prober is used even when disabled if nothing else is left
+	if len(cfg.probers) == 0 && (cfg.icmp || len(extra) == 0) {
+		cfg.probers = []Prober{defaultProber(cfg)}
+	}
+	cfg.probers = append(cfg.probers, extra...)
+
+	return cfg
+}
+
+// defaultProber returns an ICMPProber owned by the scan, or a PingProber
+// if no ICMP socket can be opened.
+func defaultProber(cfg *scanConfig) Prober {
+	p, err := NewICMPProber()
+	if err != nil {
+		return PingProber{}
+	}
+
+	cfg.closers = append(cfg.closers, p)
+	return p
+}
+
+// close releases the resources the scan opened for itself.
+func (cfg *scanConfig) close() {
+	for _, c := range cfg.closers {
+		c.Close()
+	}
+}
+
+// WithProber sets the probers used to decide whether a host is alive.
+// When several are given they run concurrently and a host is alive as soon
+// as any of them says so. The default is an ICMPProber, or a PingProber
+// when ICMP sockets cannot be opened.
+func WithProber(probers ...Prober) Option {
+	return func(cfg *scanConfig) {
+		cfg.probers = probers
+	}
+}
+
+// WithICMP sets whether the default ICMP prober is used beside the probers
+// added by WithTCPPorts and WithARP. It is enabled by default, and is used
+// regardless if no other prober is available.
+func WithICMP(enabled bool) Option {
+	return func(cfg *scanConfig) {
+		cfg.icmp = enabled
+	}
+}
+
+// WithRetries probes hosts that did not answer up to n more times, each
+// time waiting for the full timeout. The default is no retries.
+func WithRetries(n int) Option {
+	return func(cfg *scanConfig) {
+		cfg.retries = max(n, 0)
+	}
+}
+
+// WithReverseDNS sets whether the names of alive hosts are looked up. It is
+// enabled by default.
+func WithReverseDNS(enabled bool) Option {
+	return func(cfg *scanConfig) {
+		cfg.reverseDNS = enabled
+	}
+}
+
+// WithOfflineHosts sets whether hosts that did not answer are kept in
+// ScanResult.Hosts. They are still counted in TotalHosts and passed to the
+// WithHostFunc callback. They are kept by default.
+func WithOfflineHosts(keep bool) Option {
+	return func(cfg *scanConfig) {
+		cfg.dropOffline = !keep
+	}
+}
+
+// WithTCPPorts adds a TCPProber for ports beside the other probers, so
+// hosts that drop ICMP are still found.
+func WithTCPPorts(ports ...int) Option {
+	return func(cfg *scanConfig) {
+		cfg.tcpPorts = ports
+	}
+}
+
+// WithARP adds an ARPProber on iface beside the other probers. local holds
+// the interface's IPv4 address and subnet; see NewARPProber. The option is
+// ignored if the ARP socket cannot be opened.
+func WithARP(iface *net.Interface, local *net.IPNet) Option {
+	return func(cfg *scanConfig) {
+		cfg.arpIface = iface
+		cfg.arpLocal = local
+	}
+}
+
+// WithHostFunc registers fn to be called with each host as soon as it has
+// been scanned. Calls are made sequentially from the goroutine running the
+// scan, in completion order.
+func WithHostFunc(fn func(Host)) Option {
+	return func(cfg *scanConfig) {
+		cfg.onHost = fn
+	}
+}
+
+// WithProgressFunc registers fn to be called every interval while the scan
+// runs, and once more when it finishes. Calls are made from the goroutine
+// running the scan.
+func WithProgressFunc(interval time.Duration, fn func(Progress)) Option {
+	return func(cfg *scanConfig) {
+		cfg.onProgress = fn
+		cfg.progressInterval = interval
+	}
+}
@@ -0,0 +1,126 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"time"
+)
+
+// Prober checks whether a single host is reachable.
+// Implementations must return once ctx is done; the scanner sets the
+// per-host timeout as the context deadline.
+type Prober interface {
+	Probe(ctx context.Context, ip net.IP) (ProbeResult, error)
+}
+
+// ProberFunc adapts an ordinary function to the Prober interface.
+type ProberFunc func(ctx context.Context, ip net.IP) (ProbeResult, error)
+
+// Probe calls f(ctx, ip).
+func (f ProberFunc) Probe(ctx context.Context, ip net.IP) (ProbeResult, error) {
+	return f(ctx, ip)
+}
+
+// ProbeResult describes the outcome of probing a single host.
+type ProbeResult struct {
+	Alive    bool
+	Latency  time.Duration
+	Method   string // short name of the probe, e.g. "ping"
+	Evidence string // human readable reason for the verdict
+	Port     int    // TCP port that answered, if any
+	MAC      string // hardware address learned by the probe, if any
+}
+
+// ProbeAttempt is the outcome of one prober for one host.
+type ProbeAttempt struct {
+	ProbeResult
+	Error error
+}
+
+// PingProber probes hosts by running the system ping command.
+type PingProber struct{}
+
+// Probe pings ip once, waiting until the context deadline for a reply.
+func (PingProber) Probe(ctx context.Context, ip net.IP) (ProbeResult, error) {
+	res := ProbeResult{Method: "ping"}
+
+	start := time.Now()
+	isAlive, err := pingHost(ctx, ip.String(), timeoutFromContext(ctx, time.Second))
+	res.Latency = time.Since(start)
+	res.Alive = isAlive
+
+	if isAlive {
+		res.Evidence = "ping reply"
+	}
+
+	return res, err
+}
+
+// runProbers probes ip with all probers concurrently. The first prober to
+// find the host alive wins and the others are cancelled. If no prober finds
+// the host alive their errors are joined. The outcome of every prober is
+// returned as well, in the order they finished.
+func runProbers(ctx context.Context, probers []Prober, ip net.IP) (ProbeResult, []ProbeAttempt, error) {
+	if len(probers) == 1 {
+		res, err := probers[0].Probe(ctx, ip)
+		return res, []ProbeAttempt{{res, err}}, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type outcome struct {
+		res ProbeResult
+		err error
+	}
+	outcomes := make(chan outcome, len(probers))
+	for _, p := range probers {
+		go func(p Prober) {
+			res, err := p.Probe(ctx, ip)
+			outcomes <- outcome{res, err}
+		}(p)
+	}
+
+	var attempts []ProbeAttempt
+	var winner *ProbeResult
+	for range probers {
+		o := <-outcomes
+		attempts = append(attempts, ProbeAttempt{o.res, o.err})
+		if o.res.Alive && winner == nil {
+			// Probers return promptly once cancelled, so the remaining
+			// attempts are still collected.
+			winner = &o.res
+			cancel()
+		}
+	}
+	if winner != nil {
+		return *winner, attempts, nil
+	}
+
+	var methods []string
+	var errs []error
+	var latency time.Duration
+	for _, a := range attempts {
+		methods = append(methods, a.Method)
+		errs = append(errs, a.Error)
+		latency = max(latency, a.Latency)
+	}
+
+	return ProbeResult{
+		Latency: latency,
+		Method:  strings.Join(methods, ","),
+	}, attempts, errors.Join(errs...)
+}
+
+// timeoutFromContext returns the time left until the deadline of ctx,
+// or fallback if ctx has no deadline.
+func timeoutFromContext(ctx context.Context, fallback time.Duration) time.Duration {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return fallback
+	}
+
+	return time.Until(deadline)
+}
@@ -0,0 +1,37 @@
+package scanner
+
+import "time"
+
+// Progress is a snapshot of a running scan.
+type Progress struct {
+	Done    int           // hosts probed so far
+	Total   int           // hosts in the scan
+	Alive   int           // hosts found alive so far
+	Elapsed time.Duration // time since the scan started
+}
+
+// Percent returns the completed fraction of the scan in the range 0-100.
+func (p Progress) Percent() int {
+	if p.Total == 0 {
+		return 100
+	}
+	return p.Done * 100 / p.Total
+}
+
+// Rate returns the number of hosts probed per second.
+func (p Progress) Rate() float64 {
+	if p.Elapsed <= 0 {
+		return 0
+	}
+	return float64(p.Done) / p.Elapsed.Seconds()
+}
+
+// ETA estimates the time remaining from the current rate.
+// It returns 0 when no host has completed yet.
+func (p Progress) ETA() time.Duration {
+	rate := p.Rate()
+	if rate == 0 || p.Done >= p.Total {
+		return 0
+	}
+	return time.Duration(float64(p.Total-p.Done) / rate * float64(time.Second))
+}
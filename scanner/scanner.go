@@ -1,14 +1,20 @@
 package scanner
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"iter"
 	"net"
+	"net/netip"
 	"os/exec"
 	"runtime"
+	"slices"
 	"strings"
 	"sync"
 	"time"
+
+	"hostscanner/oui"
 )
 
 // Common errors returned by this package.
@@ -24,7 +30,21 @@ type Host struct {
 	Vendor   string        `json:"vendor"`
 	Latency  time.Duration `json:"latency"`
 	IsAlive  bool          `json:"is_alive"`
+	Method   string        `json:"method,omitempty"`
+	Evidence string        `json:"evidence,omitempty"`
+	Port     int           `json:"port,omitempty"`
 	Error    error         `json:"error,omitempty"`
+
+	// Names holds every name the address resolved to; Hostname is the
+	// first of them.
+	Names []string `json:"names,omitempty"`
+
+	// Probes holds the outcome of each prober that checked the host.
+	Probes []ProbeAttempt `json:"probes,omitempty"`
+
+	// Addresses holds every address found for the host when there are
+	// several, as with DiscoverIPv6; IP is the first of them.
+	Addresses []net.IP `json:"addresses,omitempty"`
 }
 
 // ScanResult represents the complete network scan results.
@@ -33,35 +53,79 @@ type ScanResult struct {
 	TotalHosts   int           `json:"total_hosts"`
 	AliveHosts   int           `json:"alive_hosts"`
 	Hosts        []Host        `json:"hosts"`
+	StartTime    time.Time     `json:"start_time"`
 	ScanTime     time.Duration `json:"scan_time"`
+	Cancelled    bool          `json:"cancelled,omitempty"`
 }
 
 // ScanNetwork scans a network range for active hosts.
-// It uses a worker pool pattern for concurrent scanning.
-func ScanNetwork(ips []net.IP, timeout time.Duration, maxWorkers int) *ScanResult {
+// It uses a worker pool pattern for concurrent scanning. By default hosts
+// are probed with ICMP echo requests; use WithProber to supply another
+// method.
+func ScanNetwork(ips []net.IP, timeout time.Duration, maxWorkers int, opts ...Option) *ScanResult {
+	return ScanNetworkContext(context.Background(), ips, timeout, maxWorkers, opts...)
+}
+
+// ScanNetworkContext is like ScanNetwork but stops early when ctx is done.
+// No new hosts are probed after cancellation and running probes are
+// aborted. The partial result contains only the hosts that finished and
+// has Cancelled set.
+func ScanNetworkContext(ctx context.Context, ips []net.IP, timeout time.Duration, maxWorkers int, opts ...Option) *ScanResult {
+	return scan(ctx, slices.Values(ips), len(ips), timeout, maxWorkers, opts)
+}
+
+// ScanAddrs is like ScanNetworkContext but takes the addresses to probe
+// from targets while the scan runs, so the memory used for targets does
+// not grow with their number. total is the number of addresses targets
+// yields, reported in TotalHosts and progress updates. To also keep the
+// result small, combine it with WithOfflineHosts(false).
+func ScanAddrs(ctx context.Context, targets iter.Seq[netip.Addr], total int, timeout time.Duration, maxWorkers int, opts ...Option) *ScanResult {
+	ips := func(yield func(net.IP) bool) {
+		for addr := range targets {
+			b := addr.Unmap().As16()
+			if !yield(net.IP(b[:])) {
+				return
+			}
+		}
+	}
+
+	return scan(ctx, ips, total, timeout, maxWorkers, opts)
+}
+
+// scan probes the addresses of targets with a pool of maxWorkers workers,
+// at least one. The channels between them are bounded by the number of
+// workers.
+func scan(ctx context.Context, targets iter.Seq[net.IP], total int, timeout time.Duration, maxWorkers int, opts []Option) *ScanResult {
+	maxWorkers = max(maxWorkers, 1)
+	cfg := newScanConfig(timeout, opts)
+	defer cfg.close()
 	start := time.Now()
 	result := &ScanResult{
-		TotalHosts: len(ips),
-		Hosts:      make([]Host, 0, len(ips)),
+		TotalHosts: total,
+		StartTime:  start,
 	}
 
 	// Create worker pool
-	jobs := make(chan net.IP, len(ips))
-	results := make(chan Host, len(ips))
+	jobs := make(chan net.IP, maxWorkers)
+	results := make(chan Host, maxWorkers)
 
 	// Start workers
 	var wg sync.WaitGroup
 	for w := 0; w < maxWorkers; w++ {
 		wg.Add(1)
-		go worker(jobs, results, timeout, &wg)
+		go worker(ctx, jobs, results, cfg, &wg)
 	}
 
 	// Send jobs
 	go func() {
-		for _, ip := range ips {
-			jobs <- ip
+		defer close(jobs)
+		for ip := range targets {
+			select {
+			case jobs <- ip:
+			case <-ctx.Done():
+				return
+			}
 		}
-		close(jobs)
 	}()
 
 	// Collect results
@@ -70,50 +134,123 @@ func ScanNetwork(ips []net.IP, timeout time.Duration, maxWorkers int) *ScanResul
 		close(results)
 	}()
 
+	done := 0
+	progress := func() Progress {
+		return Progress{
+			Done:    done,
+			Total:   result.TotalHosts,
+			Alive:   result.AliveHosts,
+			Elapsed: time.Since(start),
+		}
+	}
+
+	var tick <-chan time.Time
+	if cfg.onProgress != nil && cfg.progressInterval > 0 {
+		ticker := time.NewTicker(cfg.progressInterval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
 	// Process results
-	for host := range results {
-		result.Hosts = append(result.Hosts, host)
-		if host.IsAlive {
-			result.AliveHosts++
+	for results != nil {
+		select {
+		case host, ok := <-results:
+			if !ok {
+				results = nil
+				break
+			}
+
+			done++
+			if host.IsAlive {
+				result.AliveHosts++
+			}
+			if host.IsAlive || !cfg.dropOffline {
+				result.Hosts = append(result.Hosts, host)
+			}
+			if cfg.onHost != nil {
+				cfg.onHost(host)
+			}
+		case <-tick:
+			cfg.onProgress(progress())
 		}
 	}
 
+	if cfg.onProgress != nil {
+		cfg.onProgress(progress())
+	}
+
+	result.Cancelled = ctx.Err() != nil
 	result.ScanTime = time.Since(start)
 	return result
 }
 
 // worker performs host discovery for each IP.
-func worker(jobs <-chan net.IP, results chan<- Host, timeout time.Duration, wg *sync.WaitGroup) {
+func worker(ctx context.Context, jobs <-chan net.IP, results chan<- Host, cfg *scanConfig, wg *sync.WaitGroup) {
 	defer wg.Done()
 
 	for ip := range jobs {
-		host := scanHost(ip, timeout)
+		if ctx.Err() != nil {
+			return
+		}
+
+		host := scanHost(ctx, ip, cfg)
+		if ctx.Err() != nil && !host.IsAlive {
+			// The probe was cut short, so the host's state is unknown.
+			return
+		}
 		results <- host
 	}
 }
 
 // scanHost checks if a host is alive and gathers information.
-func scanHost(ip net.IP, timeout time.Duration) Host {
+func scanHost(ctx context.Context, ip net.IP, cfg *scanConfig) Host {
 	host := Host{
 		IP:      ip,
 		IsAlive: false,
 	}
 
-	// Ping the host
-	start := time.Now()
-	isAlive, err := pingHost(ip.String(), timeout)
-	host.Latency = time.Since(start)
-	host.IsAlive = isAlive
+	// Probe the host, again if it did not answer and retries are enabled
+	var (
+		answered time.Time
+		res      ProbeResult
+		attempts []ProbeAttempt
+		err      error
+	)
+	for try := 0; ; try++ {
+		var tried []ProbeAttempt
+		probeCtx, cancel := context.WithTimeout(ctx, cfg.timeout)
+		res, tried, err = runProbers(probeCtx, cfg.probers, ip)
+		cancel()
+		answered = time.Now()
+
+		attempts = append(attempts, tried...)
+		if res.Alive || try >= cfg.retries || ctx.Err() != nil {
+			break
+		}
+	}
+
+	host.Latency = res.Latency
+	host.IsAlive = res.Alive
+	host.Method = res.Method
+	host.Evidence = res.Evidence
+	host.Port = res.Port
 	host.Error = err
+	host.Probes = attempts
 
-	if isAlive {
+	if host.IsAlive && cfg.reverseDNS {
 		// Try to resolve hostname
-		if names, err := net.LookupAddr(ip.String()); err == nil && len(names) > 0 {
-			host.Hostname = strings.TrimSuffix(names[0], ".")
+		if host.Names = lookupNames(ctx, ip); len(host.Names) > 0 {
+			host.Hostname = host.Names[0]
 		}
+	}
 
+	if host.IsAlive {
 		// Try to get MAC address (works better on local network)
-		if mac := getMACAddress(ip.String()); mac != "" {
+		mac := res.MAC
+		if mac == "" {
+			mac = cfg.neighbors.lookupMAC(ctx, ip, answered)
+		}
+		if mac != "" {
 			host.MAC = mac
 			host.Vendor = getVendorFromMAC(mac)
 		}
@@ -122,15 +259,29 @@ func scanHost(ip net.IP, timeout time.Duration) Host {
 	return host
 }
 
+// lookupNames returns the names ip resolves to, without trailing dots.
+func lookupNames(ctx context.Context, ip net.IP) []string {
+	names, err := net.DefaultResolver.LookupAddr(ctx, ip.String())
+	if err != nil {
+		return nil
+	}
+	for i, name := range names {
+		names[i] = strings.TrimSuffix(name, ".")
+	}
+
+	return names
+}
+
 // pingHost pings a host to check if it's alive.
-func pingHost(ip string, timeout time.Duration) (bool, error) {
+// The ping process is killed if ctx is done before it exits.
+func pingHost(ctx context.Context, ip string, timeout time.Duration) (bool, error) {
 	var cmd *exec.Cmd
 
 	switch runtime.GOOS {
 	case "windows":
-		cmd = exec.Command("ping", "-n", "1", "-w", fmt.Sprintf("%.0f", timeout.Seconds()*1000), ip)
+		cmd = exec.CommandContext(ctx, "ping", "-n", "1", "-w", fmt.Sprintf("%.0f", timeout.Seconds()*1000), ip)
 	case "darwin", "linux":
-		cmd = exec.Command("ping", "-c", "1", "-W", fmt.Sprintf("%.0f", timeout.Seconds()*1000), ip)
+		cmd = exec.CommandContext(ctx, "ping", "-c", "1", "-W", fmt.Sprintf("%.0f", timeout.Seconds()*1000), ip)
 	default:
 		return false, fmt.Errorf("%w: %s", ErrUnsupportedOS, runtime.GOOS)
 	}
@@ -141,14 +292,14 @@ func pingHost(ip string, timeout time.Duration) (bool, error) {
 
 // getMACAddress attempts to get MAC address using ARP table.
 // It returns an empty string if the MAC address cannot be determined.
-func getMACAddress(ip string) string {
+func getMACAddress(ctx context.Context, ip string) string {
 	var cmd *exec.Cmd
 
 	switch runtime.GOOS {
 	case "windows":
-		cmd = exec.Command("arp", "-a", ip)
+		cmd = exec.CommandContext(ctx, "arp", "-a", ip)
 	case "darwin", "linux":
-		cmd = exec.Command("arp", "-n", ip)
+		cmd = exec.CommandContext(ctx, "arp", "-n", ip)
 	default:
 		return ""
 	}
@@ -177,35 +328,8 @@ func getMACAddress(ip string) string {
 // getVendorFromMAC returns vendor information based on MAC address OUI.
 // It returns "Unknown" if the vendor cannot be determined.
 func getVendorFromMAC(mac string) string {
-	if len(mac) < 8 {
-		return "Unknown"
-	}
-
-	// Extract OUI (first 3 octets)
-	oui := strings.ReplaceAll(mac[:8], ":", "")
-	oui = strings.ReplaceAll(oui, "-", "")
-	oui = strings.ToUpper(oui)
-
-	// Common vendor mappings based on OUI database
-	vendors := map[string]string{
-		"005056": "VMware",
-		"080027": "Oracle VirtualBox",
-		"525400": "QEMU/KVM",
-		"B827EB": "Raspberry Pi Foundation",
-		"DCA632": "Raspberry Pi Foundation",
-		"E45F01": "Raspberry Pi Foundation",
-		"00163E": "Xen",
-		"001C42": "Parallels",
-		"ACDE48": "Apple",
-		"F8FFC2": "Apple",
-		"28CDC1": "Apple",
-		"3C0754": "Apple",
-	}
-
-	for ouiPrefix, vendor := range vendors {
-		if strings.HasPrefix(oui, ouiPrefix) {
-			return vendor
-		}
+	if vendor, ok := oui.Lookup(mac); ok {
+		return vendor
 	}
 
 	return "Unknown"
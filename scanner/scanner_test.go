@@ -1,7 +1,13 @@
 package scanner_test
 
 import (
+	"context"
+	"errors"
 	"net"
+	"net/netip"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -26,3 +32,311 @@ func TestScanNetwork(t *testing.T) {
 	assert.True(t, result.Hosts[0].IsAlive, "Localhost should be alive")
 	assert.Equal(t, "127.0.0.1", result.Hosts[0].IP.String())
 }
+
+// fakeProber reports the hosts in alive as reachable.
+type fakeProber struct {
+	alive map[string]bool
+}
+
+func (p fakeProber) Probe(ctx context.Context, ip net.IP) (scanner.ProbeResult, error) {
+	if !p.alive[ip.String()] {
+		return scanner.ProbeResult{Method: "fake"}, errors.New("no reply")
+	}
+	return scanner.ProbeResult{
+		Alive:    true,
+		Latency:  time.Millisecond,
+		Method:   "fake",
+		Evidence: "fake reply",
+	}, nil
+}
+
+func TestScanNetwork_WithProber(t *testing.T) {
+	ips := []net.IP{
+		net.ParseIP("192.0.2.1"),
+		net.ParseIP("192.0.2.2"),
+		net.ParseIP("192.0.2.3"),
+	}
+	prober := fakeProber{alive: map[string]bool{"192.0.2.2": true}}
+
+	result := scanner.ScanNetwork(ips, 100*time.Millisecond, 2, scanner.WithProber(prober))
+
+	assert.Equal(t, 3, result.TotalHosts)
+	assert.Equal(t, 3, len(result.Hosts))
+	assert.Equal(t, 1, result.AliveHosts)
+	for _, host := range result.Hosts {
+		assert.Equal(t, "fake", host.Method)
+		if host.IP.String() == "192.0.2.2" {
+			assert.True(t, host.IsAlive)
+			assert.Equal(t, time.Millisecond, host.Latency)
+			assert.Equal(t, "fake reply", host.Evidence)
+			assert.NoError(t, host.Error)
+		} else {
+			assert.False(t, host.IsAlive)
+			assert.Error(t, host.Error)
+		}
+	}
+}
+
+func TestScanNetwork_NoWorkers(t *testing.T) {
+	ips := []net.IP{net.ParseIP("192.0.2.1"), net.ParseIP("192.0.2.2")}
+	prober := fakeProber{alive: map[string]bool{"192.0.2.2": true}}
+
+	// Zero or negative workers still scan, with a single worker
+	for _, workers := range []int{0, -1} {
+		result := scanner.ScanNetwork(ips, 100*time.Millisecond, workers, scanner.WithProber(prober))
+		assert.Len(t, result.Hosts, 2)
+		assert.Equal(t, 1, result.AliveHosts)
+	}
+}
+
+func TestScanNetwork_ProberDeadline(t *testing.T) {
+	// The prober must see the scan timeout as its context deadline.
+	prober := scanner.ProberFunc(func(ctx context.Context, ip net.IP) (scanner.ProbeResult, error) {
+		<-ctx.Done()
+		return scanner.ProbeResult{}, ctx.Err()
+	})
+
+	start := time.Now()
+	result := scanner.ScanNetwork([]net.IP{net.ParseIP("192.0.2.1")}, 50*time.Millisecond, 1, scanner.WithProber(prober))
+
+	assert.Less(t, time.Since(start), time.Second)
+	assert.ErrorIs(t, result.Hosts[0].Error, context.DeadlineExceeded)
+}
+
+func TestScanNetworkContext_Cancel(t *testing.T) {
+	ips := make([]net.IP, 100)
+	for i := range ips {
+		ips[i] = net.IPv4(192, 0, 2, byte(i))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var probed atomic.Int32
+	prober := scanner.ProberFunc(func(ctx context.Context, ip net.IP) (scanner.ProbeResult, error) {
+		if probed.Add(1) < 5 {
+			return scanner.ProbeResult{}, errors.New("no reply")
+		}
+		cancel()
+		<-ctx.Done()
+		return scanner.ProbeResult{}, ctx.Err()
+	})
+
+	result := scanner.ScanNetworkContext(ctx, ips, time.Minute, 2, scanner.WithProber(prober))
+
+	assert.True(t, result.Cancelled)
+	assert.Equal(t, 100, result.TotalHosts)
+	assert.Less(t, len(result.Hosts), 100)
+	assert.LessOrEqual(t, probed.Load(), int32(6))
+}
+
+func TestScanNetwork_Streaming(t *testing.T) {
+	ips := []net.IP{
+		net.ParseIP("192.0.2.1"),
+		net.ParseIP("192.0.2.2"),
+		net.ParseIP("192.0.2.3"),
+	}
+	prober := fakeProber{alive: map[string]bool{"192.0.2.1": true, "192.0.2.3": true}}
+
+	var streamed []scanner.Host
+	var updates []scanner.Progress
+	result := scanner.ScanNetwork(ips, 100*time.Millisecond, 2,
+		scanner.WithProber(prober),
+		scanner.WithHostFunc(func(host scanner.Host) {
+			streamed = append(streamed, host)
+		}),
+		scanner.WithProgressFunc(time.Millisecond, func(p scanner.Progress) {
+			updates = append(updates, p)
+		}))
+
+	assert.ElementsMatch(t, result.Hosts, streamed)
+	if assert.NotEmpty(t, updates) {
+		last := updates[len(updates)-1]
+		assert.Equal(t, 3, last.Done)
+		assert.Equal(t, 3, last.Total)
+		assert.Equal(t, 2, last.Alive)
+		assert.Equal(t, 100, last.Percent())
+		assert.Zero(t, last.ETA())
+	}
+}
+
+func TestProgress_ETA(t *testing.T) {
+	p := scanner.Progress{Done: 25, Total: 100, Elapsed: 5 * time.Second}
+
+	assert.Equal(t, 25, p.Percent())
+	assert.InDelta(t, 5.0, p.Rate(), 0.001)
+	assert.Equal(t, 15*time.Second, p.ETA())
+	assert.Zero(t, scanner.Progress{Total: 100}.ETA())
+}
+
+func TestICMPProber_Localhost(t *testing.T) {
+	prober, err := scanner.NewICMPProber()
+	if err != nil {
+		t.Skipf("ICMP sockets not available: %v", err)
+	}
+	defer prober.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	// Concurrent probes share the socket and must each get their reply.
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res, err := prober.Probe(ctx, net.ParseIP("127.0.0.1"))
+			assert.NoError(t, err)
+			assert.True(t, res.Alive)
+			assert.Equal(t, "icmp", res.Method)
+		}()
+	}
+	wg.Wait()
+}
+
+func TestTCPProber(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer ln.Close()
+	openPort := ln.Addr().(*net.TCPAddr).Port
+
+	// A listener closed right away leaves a port that answers with RST.
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	closedPort := closed.Addr().(*net.TCPAddr).Port
+	closed.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	res, err := scanner.TCPProber{Ports: []int{openPort}}.Probe(ctx, net.ParseIP("127.0.0.1"))
+	assert.NoError(t, err)
+	assert.True(t, res.Alive)
+	assert.Equal(t, openPort, res.Port)
+	assert.Contains(t, res.Evidence, "open")
+
+	res, err = scanner.TCPProber{Ports: []int{closedPort}}.Probe(ctx, net.ParseIP("127.0.0.1"))
+	assert.NoError(t, err)
+	assert.True(t, res.Alive)
+	assert.Equal(t, closedPort, res.Port)
+	assert.Contains(t, res.Evidence, "reset")
+
+	open := scanner.TCPProber{Ports: []int{closedPort, openPort}}.OpenPorts(ctx, net.ParseIP("127.0.0.1"))
+	assert.Equal(t, []int{openPort}, open)
+}
+
+func TestScanNetwork_MultipleProbers(t *testing.T) {
+	ips := []net.IP{
+		net.ParseIP("192.0.2.1"),
+		net.ParseIP("192.0.2.2"),
+	}
+	icmp := fakeProber{alive: map[string]bool{"192.0.2.1": true}}
+	tcp := scanner.ProberFunc(func(ctx context.Context, ip net.IP) (scanner.ProbeResult, error) {
+		if ip.String() != "192.0.2.2" {
+			return scanner.ProbeResult{Method: "tcp"}, errors.New("filtered")
+		}
+		return scanner.ProbeResult{Alive: true, Method: "tcp", Port: 445}, nil
+	})
+
+	result := scanner.ScanNetwork(ips, 100*time.Millisecond, 2, scanner.WithProber(icmp, tcp))
+
+	assert.Equal(t, 2, result.AliveHosts)
+	for _, host := range result.Hosts {
+		// Every prober's outcome is kept, including the losing one
+		assert.Len(t, host.Probes, 2)
+		if host.IP.String() == "192.0.2.2" {
+			assert.Equal(t, "tcp", host.Method)
+			assert.Equal(t, 445, host.Port)
+		} else {
+			assert.Equal(t, "fake", host.Method)
+		}
+	}
+}
+
+func TestParseProcNetARP(t *testing.T) {
+	table := `IP address       HW type     Flags       HW address            Mask     Device
+192.168.1.1      0x1         0x2         aa:bb:cc:dd:ee:ff     *        eth0
+192.168.1.7      0x1         0x0         00:00:00:00:00:00     *        eth0
+10.0.0.1         0x1         0x6         52:54:00:12:34:56     *        br0
+`
+	neighbors, err := scanner.ParseProcNetARP(strings.NewReader(table))
+	assert.NoError(t, err)
+	assert.Len(t, neighbors, 3)
+
+	assert.Equal(t, "192.168.1.1", neighbors[0].IP.String())
+	assert.Equal(t, "AA:BB:CC:DD:EE:FF", neighbors[0].MAC)
+	assert.Equal(t, "eth0", neighbors[0].Interface)
+	assert.Equal(t, scanner.NeighborComplete, neighbors[0].State)
+
+	assert.Equal(t, scanner.NeighborIncomplete, neighbors[1].State)
+	assert.Empty(t, neighbors[1].MAC)
+
+	assert.Equal(t, scanner.NeighborPermanent, neighbors[2].State)
+	assert.Equal(t, "br0", neighbors[2].Interface)
+}
+
+func TestScanNetwork_Retries(t *testing.T) {
+	ips := []net.IP{net.ParseIP("127.0.0.1")}
+
+	// The host only answers every second probe
+	var calls atomic.Int32
+	flaky := scanner.ProberFunc(func(ctx context.Context, ip net.IP) (scanner.ProbeResult, error) {
+		if calls.Add(1)%2 == 1 {
+			return scanner.ProbeResult{Method: "flaky"}, errors.New("no reply")
+		}
+		return scanner.ProbeResult{Alive: true, Method: "flaky"}, nil
+	})
+
+	result := scanner.ScanNetwork(ips, 100*time.Millisecond, 1,
+		scanner.WithProber(flaky), scanner.WithReverseDNS(false))
+	assert.Equal(t, 0, result.AliveHosts)
+
+	calls.Store(0)
+	result = scanner.ScanNetwork(ips, 100*time.Millisecond, 1,
+		scanner.WithProber(flaky), scanner.WithRetries(2), scanner.WithReverseDNS(false))
+	assert.Equal(t, 1, result.AliveHosts)
+	assert.Len(t, result.Hosts[0].Probes, 2)
+	assert.Empty(t, result.Hosts[0].Hostname)
+	assert.EqualValues(t, 2, calls.Load())
+}
+
+func TestScanAddrs_Bounded(t *testing.T) {
+	const total, workers = 20000, 4
+
+	// Every 1000th address answers
+	prober := scanner.ProberFunc(func(ctx context.Context, ip net.IP) (scanner.ProbeResult, error) {
+		ip4 := ip.To4()
+		n := int(ip4[2])<<8 | int(ip4[3])
+		return scanner.ProbeResult{Alive: n%1000 == 0, Method: "fake"}, nil
+	})
+
+	var yielded atomic.Int64
+	targets := func(yield func(netip.Addr) bool) {
+		addr := netip.MustParseAddr("10.0.0.0")
+		for range total {
+			yielded.Add(1)
+			if !yield(addr) {
+				return
+			}
+			addr = addr.Next()
+		}
+	}
+
+	// Targets are only taken as workers become free
+	done, ahead := 0, int64(0)
+	result := scanner.ScanAddrs(context.Background(), targets, total, time.Second, workers,
+		scanner.WithProber(prober),
+		scanner.WithReverseDNS(false),
+		scanner.WithOfflineHosts(false),
+		scanner.WithHostFunc(func(scanner.Host) {
+			done++
+			ahead = max(ahead, yielded.Load()-int64(done))
+		}))
+
+	assert.Equal(t, total, done)
+	assert.LessOrEqual(t, ahead, int64(3*workers+2))
+	assert.Equal(t, total, result.TotalHosts)
+	assert.Equal(t, total/1000, result.AliveHosts)
+	assert.Len(t, result.Hosts, total/1000)
+	for _, host := range result.Hosts {
+		assert.True(t, host.IsAlive)
+	}
+}
@@ -0,0 +1,120 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// DefaultTCPPorts are the ports tried by a TCPProber without explicit ports.
+// They cover services commonly left reachable by hosts that drop ICMP.
+var DefaultTCPPorts = []int{22, 80, 443, 445, 3389}
+
+// TCPProber detects hosts by opening TCP connections to a list of ports.
+// A host is alive if any port completes the handshake or actively refuses
+// the connection with a reset; only silence counts as offline.
+type TCPProber struct {
+	Ports []int
+}
+
+// Probe connects to all ports of ip concurrently and reports the first
+// port that answers.
+func (p TCPProber) Probe(ctx context.Context, ip net.IP) (ProbeResult, error) {
+	res := ProbeResult{Method: "tcp"}
+
+	ports := p.Ports
+	if len(ports) == 0 {
+		ports = DefaultTCPPorts
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type answer struct {
+		port     int
+		refused  bool
+		received time.Time
+		err      error
+	}
+	answers := make(chan answer, len(ports))
+
+	start := time.Now()
+	for _, port := range ports {
+		go func(port int) {
+			var d net.Dialer
+			conn, err := d.DialContext(ctx, "tcp", net.JoinHostPort(ip.String(), strconv.Itoa(port)))
+			a := answer{port: port, received: time.Now(), err: err}
+			if err == nil {
+				conn.Close()
+			} else if errors.Is(err, syscall.ECONNREFUSED) {
+				a.refused = true
+				a.err = nil
+			}
+			answers <- a
+		}(port)
+	}
+
+	var errs []error
+	for range ports {
+		a := <-answers
+		if a.err != nil {
+			errs = append(errs, a.err)
+			continue
+		}
+
+		res.Alive = true
+		res.Latency = a.received.Sub(start)
+		res.Port = a.port
+		if a.refused {
+			res.Evidence = fmt.Sprintf("tcp/%d reset", a.port)
+		} else {
+			res.Evidence = fmt.Sprintf("tcp/%d open", a.port)
+		}
+		return res, nil
+	}
+
+	res.Latency = time.Since(start)
+	if ctx.Err() != nil {
+		return res, ctx.Err()
+	}
+	return res, errors.Join(errs...)
+}
+
+// OpenPorts connects to all ports of ip concurrently and returns, in
+// ascending order, those that completed the handshake. Unlike Probe it
+// waits for every port to answer or for the context to be done.
+func (p TCPProber) OpenPorts(ctx context.Context, ip net.IP) []int {
+	ports := p.Ports
+	if len(ports) == 0 {
+		ports = DefaultTCPPorts
+	}
+
+	open := make(chan int, len(ports))
+	for _, port := range ports {
+		go func(port int) {
+			var d net.Dialer
+			conn, err := d.DialContext(ctx, "tcp", net.JoinHostPort(ip.String(), strconv.Itoa(port)))
+			if err != nil {
+				open <- 0
+				return
+			}
+			conn.Close()
+			open <- port
+		}(port)
+	}
+
+	var found []int
+	for range ports {
+		if port := <-open; port != 0 {
+			found = append(found, port)
+		}
+	}
+	slices.Sort(found)
+
+	return found
+}